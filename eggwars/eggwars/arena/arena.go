@@ -2,83 +2,28 @@ package arena
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
 	"github.com/eggwars-dragonfly/eggwars/eggwars/generator"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/kit"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/match"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/npc"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/shop"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/stats"
 	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
 
 	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/item"
 	"github.com/df-mc/dragonfly/server/player"
-	"github.com/df-mc/dragonfly/server/player/form"
+	"github.com/df-mc/dragonfly/server/player/skin"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/sirupsen/logrus"
 )
 
-type GameState int
-
-const (
-	Waiting GameState = iota
-	Starting
-	Playing
-	Ending
-)
-
-// shopMenu is a MenuSubmittable used for the in-game shop.
-type shopMenu struct {
-	a      *Arena
-	Iron   form.Button
-	Sword  form.Button
-	Shield form.Button
-	Close  form.Button
-}
-
-func (m shopMenu) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
-	p, ok := submitter.(*player.Player)
-	if !ok {
-		return
-	}
-
-	if pressed == m.Close {
-		p.Message("§aShop closed!")
-		return
-	}
-
-	pd := m.a.GetPlayerData(p.Name())
-	if pd == nil {
-		p.Message("§c✗ Error processing purchase")
-		return
-	}
-
-	switch {
-	case pressed == m.Iron:
-		if iron, ok := pd.Resources["iron"]; ok && iron >= 10 {
-			pd.Resources["iron"] -= 10
-			p.Message("§a✓ Purchased Iron Helmet!")
-		} else {
-			p.Message("§c✗ Not enough Iron! Need: 10, Have: " + fmt.Sprint(pd.Resources["iron"]))
-		}
-	case pressed == m.Sword:
-		if diamond, ok := pd.Resources["diamond"]; ok && diamond >= 5 {
-			pd.Resources["diamond"] -= 5
-			p.Message("§a✓ Purchased Diamond Sword!")
-		} else {
-			p.Message("§c✗ Not enough Diamond! Need: 5, Have: " + fmt.Sprint(pd.Resources["diamond"]))
-		}
-	case pressed == m.Shield:
-		gold := pd.Resources["gold"]
-		iron := pd.Resources["iron"]
-		if gold >= 5 && iron >= 10 {
-			pd.Resources["gold"] -= 5
-			pd.Resources["iron"] -= 10
-			p.Message("§a✓ Purchased Shield!")
-		} else {
-			p.Message(fmt.Sprintf("§c✗ Not enough resources! Need: 5 Gold + 10 Iron, Have: %d Gold + %d Iron", gold, iron))
-		}
-	}
-}
-
 type Arena struct {
 	Name         string
 	Config       *config.ArenaConfig
@@ -86,24 +31,107 @@ type Arena struct {
 	Teams        map[team.Color]*team.Team
 	Players      map[string]*PlayerData
 	PlacedBlocks map[cube.Pos]bool
-	Generators   map[team.Color]*generator.Generator
-	World        *world.World
-	log          *logrus.Logger
-	mu           sync.RWMutex
-	startTimer   *time.Timer
+	Generators   []*generator.Generator
+	// npcs holds the shopkeepers standing on the islands during a match.
+	npcs []*npc.NPC
+	// npcSkin is the skin worn by all shopkeepers. It is nil if shopkeepers
+	// wear plain skins in their team's colour.
+	npcSkin *skin.Skin
+	// World is the world the current match is played in. It is a fresh copy
+	// of the arena's template map and is nil while no match is running,
+	// unless instances are reused, in which case it is rolled back through
+	// Journal and kept for the next match.
+	World    *world.World
+	Lobby    *world.World
+	Journal  *Journal
+	shop     *shop.Shop
+	kits     *kit.Kits
+	stats    *stats.StatsManager
+	global   *config.Config
+	instance *instance
+	log      *logrus.Logger
+	mu       sync.RWMutex
+
+	// now is the time of the last tick of the game loop. Events handled
+	// between two ticks use it as their time.
+	now         time.Time
+	phaseEnd    time.Time
+	lastCount   int
+	opening     bool
+	instanceErr error
+	winner      *team.Team
+	// match is the log of the match being played. It is nil while no match
+	// is running.
+	match *match.Log
+	// leavers holds the IDs of the players that left the running match.
+	leavers []string
+	jobs    []func()
+	// scoreboardAt is the time the scoreboards were last updated.
+	scoreboardAt time.Time
+	// upgradesAt is the time the island effects of team upgrades were last
+	// given out.
+	upgradesAt time.Time
+	// platforms holds the rescue platforms that have yet to disappear.
+	platforms []platform
+	closing   chan struct{}
 }
 
 type PlayerData struct {
-	Player    *player.Player
-	Team      *team.Team
-	Arena     *Arena
-	Kills     int
-	Deaths    int
-	IsAlive   bool
-	Resources map[string]int
+	Player *player.Player
+	// ID is the ID the player's stats are stored under.
+	ID            string
+	Team          *team.Team
+	Arena         *Arena
+	Kills         int
+	Assists       int
+	Deaths        int
+	EggsDestroyed int
+	BlocksPlaced  int
+	IsAlive       bool
+	// Group is the ID of the group of friends the player queued with. Players
+	// of the same group are put in the same team where possible. It is empty
+	// for players that queued alone.
+	Group string
+	// Spectator is true for players watching the match, either because they
+	// were eliminated or because they joined it through Spectate.
+	Spectator bool
+
+	// respawnAt is the time the player may return to its team's spawn after
+	// dying. It is zero while the player is not waiting to respawn.
+	respawnAt time.Time
+	// respawnCount is the last second of the respawn countdown shown.
+	respawnCount int
+	// dead is true while the player is on the respawn screen.
+	dead bool
+	// hits holds the last hit of every player that attacked this player,
+	// oldest first.
+	hits []hit
+	// rejoinBy is the time a player that disconnected during the match must
+	// rejoin it by. It is zero while the player is connected.
+	rejoinBy time.Time
+	// items and armour hold what a disconnected player carried, given back
+	// when it rejoins.
+	items, armour []item.Stack
+	// joinedAt is the time the player joined the arena.
+	joinedAt time.Time
+	// playedFrom and playedUntil are the times the player started and
+	// stopped playing in the current match.
+	playedFrom, playedUntil time.Time
+	// scoreboard holds the lines of the scoreboard last sent to the player.
+	scoreboard []string
 }
 
-func NewArena(name string, cfg *config.ArenaConfig, log *logrus.Logger, w *world.World) *Arena {
+// NewArena creates an arena and starts its game loop, which runs until the
+// arena is closed.
+func NewArena(name string, cfg *config.ArenaConfig, global *config.Config, log *logrus.Logger, lobby *world.World, s *shop.Shop, ks *kit.Kits, st *stats.StatsManager) *Arena {
+	a := newArena(name, cfg, global, log, lobby, s, ks, st, time.Now())
+	go a.run()
+	return a
+}
+
+// newArena creates an arena whose clock starts at now, without starting its
+// game loop. The arena only changes state when Tick is called.
+func newArena(name string, cfg *config.ArenaConfig, global *config.Config, log *logrus.Logger, lobby *world.World, s *shop.Shop, ks *kit.Kits, st *stats.StatsManager, now time.Time) *Arena {
 	a := &Arena{
 		Name:         name,
 		Config:       cfg,
@@ -111,38 +139,64 @@ func NewArena(name string, cfg *config.ArenaConfig, log *logrus.Logger, w *world
 		Teams:        make(map[team.Color]*team.Team),
 		Players:      make(map[string]*PlayerData),
 		PlacedBlocks: make(map[cube.Pos]bool),
-		Generators:   make(map[team.Color]*generator.Generator),
-		World:        w,
+		Lobby:        lobby,
+		Journal:      NewJournal(),
+		shop:         s,
+		kits:         ks,
+		stats:        st,
+		global:       global,
 		log:          log,
+		now:          now,
+		closing:      make(chan struct{}),
 	}
 
 	a.initTeams()
-	a.initGenerators()
+	a.loadNPCSkin()
 	return a
 }
 
 func (a *Arena) initTeams() {
-	if red, ok := a.Config.Teams["red"]; ok {
-		a.Teams[team.Red] = team.NewTeam("Red", team.Red, "red", red.Spawn, red.Egg, red.Generator)
+	for name, cfg := range a.Config.Teams {
+		c, ok := team.ParseColor(name)
+		if !ok {
+			a.log.Warnf("Arena %s: unknown team colour %q", a.Name, name)
+			continue
+		}
+		a.Teams[c] = team.NewTeam(c, cfg.Spawn, cfg.Egg)
 	}
-	if blue, ok := a.Config.Teams["blue"]; ok {
-		a.Teams[team.Blue] = team.NewTeam("Blue", team.Blue, "blue", blue.Spawn, blue.Egg, blue.Generator)
+}
+
+// Validate checks if the arena configuration passed can be played: it must
+// have between config.MinTeams and config.MaxTeams teams, all with a known
+// colour.
+func Validate(cfg *config.ArenaConfig) error {
+	if n := len(cfg.Teams); n < config.MinTeams || n > config.MaxTeams {
+		return fmt.Errorf("arena has %d teams, must have between %d and %d", n, config.MinTeams, config.MaxTeams)
 	}
-	if green, ok := a.Config.Teams["green"]; ok {
-		a.Teams[team.Green] = team.NewTeam("Green", team.Green, "green", green.Spawn, green.Egg, green.Generator)
+	for name := range cfg.Teams {
+		if _, ok := team.ParseColor(name); !ok {
+			return fmt.Errorf("unknown team colour %q", name)
+		}
 	}
-	if yellow, ok := a.Config.Teams["yellow"]; ok {
-		a.Teams[team.Yellow] = team.NewTeam("Yellow", team.Yellow, "yellow", yellow.Spawn, yellow.Egg, yellow.Generator)
+	if cfg.MinPlayers > cfg.MaxPlayers {
+		return fmt.Errorf("min_players %d is more than the %d players that fit", cfg.MinPlayers, cfg.MaxPlayers)
 	}
+	return nil
 }
 
 func (a *Arena) initGenerators() {
+	a.Generators = nil
 	if a.World == nil {
 		return
 	}
 
-	for color, t := range a.Teams {
-		a.Generators[color] = generator.NewGenerator(t.Generator, generator.Iron, a.World)
+	for i, cfg := range a.Config.Generators {
+		g, err := generator.New(cfg)
+		if err != nil {
+			a.log.Errorf("Invalid generator %d in arena %s: %v", i, a.Name, err)
+			continue
+		}
+		a.Generators = append(a.Generators, g)
 	}
 }
 
@@ -158,46 +212,42 @@ func (a *Arena) AddPlayer(p *player.Player, externalPd *PlayerData) bool {
 		return false
 	}
 
-	assignedTeam := a.getSmallestTeam()
-	if assignedTeam == nil {
+	if len(a.Teams) == 0 {
 		return false
 	}
 
 	if externalPd != nil {
-		externalPd.Team = assignedTeam
+		externalPd.Team = nil
+		externalPd.scoreboard = nil
 		externalPd.Arena = a
 		externalPd.IsAlive = true
 		externalPd.Kills = 0
+		externalPd.Assists = 0
 		externalPd.Deaths = 0
-		if externalPd.Resources == nil {
-			externalPd.Resources = make(map[string]int)
-		}
+		externalPd.EggsDestroyed = 0
+		externalPd.BlocksPlaced = 0
+		externalPd.joinedAt = a.now
 		a.Players[p.Name()] = externalPd
 	} else {
 		pd := &PlayerData{
-			Player:    p,
-			Team:      assignedTeam,
-			Arena:     a,
-			IsAlive:   true,
-			Resources: make(map[string]int),
+			Player:   p,
+			Arena:    a,
+			IsAlive:  true,
+			joinedAt: a.now,
 		}
 		a.Players[p.Name()] = pd
 	}
 
-	assignedTeam.AddPlayer(p.Name())
-
+	a.giveLobbyKit(p)
 	p.Teleport(a.Config.LobbySpawn)
-	p.Message(fmt.Sprintf("<green>✓ Joined arena '%s' as %steam %s!</green>", a.Name, assignedTeam.Color, assignedTeam.ColorName))
-	a.broadcast(fmt.Sprintf("%s%s<white> joined the game! (%d/%d)</white>",
-		assignedTeam.Color, p.Name(), len(a.Players), a.Config.MaxPlayers))
-
-	if len(a.Players) >= a.Config.MinPlayers && a.State == Waiting {
-		a.startCountdown()
-	}
-
+	p.Message(fmt.Sprintf("<green>✓ Joined arena '%s'! Use the team selector to pick a team.</green>", a.Name))
+	a.broadcast(fmt.Sprintf("§7%s<white> joined the game! (%d/%d)</white>",
+		p.Name(), len(a.Players), a.Config.MaxPlayers))
 	return true
 }
 
+// RemovePlayer removes p from the arena, after which its PlayerData no longer
+// refers to the arena or a team.
 func (a *Arena) RemovePlayer(p *player.Player) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -206,201 +256,278 @@ func (a *Arena) RemovePlayer(p *player.Player) {
 	if !ok {
 		return
 	}
-
-	if pd.Team != nil {
-		pd.Team.RemovePlayer(p.Name())
-	}
-
-	delete(a.Players, p.Name())
-
+	a.removePlayer(pd)
+	pd.Arena, pd.Team, pd.IsAlive, pd.Spectator = nil, nil, false, false
 	a.broadcast(fmt.Sprintf("<yellow>%s left the game!</yellow>", p.Name()))
 
-	if a.State == Playing {
-		a.checkWinCondition()
-	} else if len(a.Players) < a.Config.MinPlayers && a.State == Starting {
-		a.cancelCountdown()
-	}
+	a.checkWinCondition(a.now)
 }
 
-func (a *Arena) getSmallestTeam() *team.Team {
-	var smallest *team.Team
-	minPlayers := 999
-
-	for _, t := range a.Teams {
-		if t.PlayerCount() < minPlayers {
-			minPlayers = t.PlayerCount()
-			smallest = t
-		}
-	}
-
-	return smallest
-}
-
-func (a *Arena) startCountdown() {
-	a.State = Starting
-	a.broadcast("<green>Game starting in 10 seconds!</green>")
-
-	a.startTimer = time.AfterFunc(10*time.Second, func() {
-		a.startGame()
-	})
-}
-
-func (a *Arena) cancelCountdown() {
-	if a.startTimer != nil {
-		a.startTimer.Stop()
-	}
-	a.State = Waiting
-	a.broadcast("<red>Not enough players! Countdown cancelled.</red>")
-}
-
-func (a *Arena) startGame() {
-	a.mu.Lock()
-	a.State = Playing
-	a.mu.Unlock()
-
-	a.broadcast("<gold>===== GAME STARTED! =====</gold>")
-	a.broadcast("<yellow>Protect your egg and destroy others!</yellow>")
-
-	for _, pd := range a.Players {
-		pd.IsAlive = true
-		if pd.Team != nil {
-			pd.Player.Teleport(pd.Team.Spawn)
-			pd.Player.Message(fmt.Sprintf("%sYou are in team %s!", pd.Team.Color, pd.Team.ColorName))
-		}
-		if pd.Resources == nil {
-			pd.Resources = make(map[string]int)
+// removePlayer removes pd from the arena and its team. Leaving a running match
+// counts as a loss. The arena's mutex must be held.
+func (a *Arena) removePlayer(pd *PlayerData) {
+	name := pd.Player.Name()
+	if pd.Team != nil {
+		pd.Team.RemovePlayer(name)
+		if a.State.InGame() {
+			// Leaving a running match counts as a loss, and is rated as
+			// finishing last.
+			a.recordStats(pd, false)
+			a.leavers = append(a.leavers, pd.ID)
 		}
-		pd.Resources["iron"] = 0
-		pd.Resources["gold"] = 0
-		pd.Resources["diamond"] = 0
 	}
 
-	for color, gen := range a.Generators {
-		if t, ok := a.Teams[color]; ok && t.EggAlive {
-			gen.Start()
-			a.log.Infof("Generator started for team %s at %v", t.Name, t.Generator)
-		}
-	}
+	delete(a.Players, name)
+	a.logEvent(match.Event{Type: match.Leave, Player: name})
 }
 
-func (a *Arena) HandlePlayerDeath(p *player.Player) {
+// HandlePlayerDeath handles the death of p to src, crediting the kill to the
+// player that killed p and to any players that assisted.
+func (a *Arena) HandlePlayerDeath(p *player.Player, src world.DamageSource) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	pd, ok := a.Players[p.Name()]
-	if !ok {
+	if !ok || pd.Spectator || !a.State.InGame() {
 		return
 	}
 
 	pd.Deaths++
+	pd.dead = true
+	pd.respawnAt, pd.respawnCount = a.now.Add(seconds(a.Config.Phases.Respawn)), 0
+	clearDrops(p)
+
+	killer, assists := a.attackers(pd, src)
+	pd.hits = nil
+	if k, ok := a.Players[killer]; ok {
+		k.Kills++
+	}
+	for _, name := range assists {
+		if as, ok := a.Players[name]; ok {
+			as.Assists++
+		}
+	}
+	msg := a.deathMessage(p.Name(), killer, assists, src)
 
-	if pd.Team != nil && pd.Team.EggAlive {
-		time.AfterFunc(3*time.Second, func() {
-			pd.IsAlive = true
-			p.Teleport(pd.Team.Spawn)
-			p.Message("<green>You respawned!</green>")
-		})
-	} else {
+	final := pd.Team == nil || !pd.Team.EggAlive
+	a.logKill(p, killer, src, final)
+	if !final {
 		pd.IsAlive = false
-		teamMsg := ""
-		if pd.Team != nil {
-			teamMsg = string(pd.Team.Color)
-		}
-		a.broadcast(fmt.Sprintf("%s%s<white> was eliminated!</white>", teamMsg, p.Name()))
-		a.checkWinCondition()
+		a.broadcast(msg)
+	} else {
+		pd.respawnAt = time.Time{}
+		a.eliminate(pd)
+		a.broadcast(msg + " §c§lFINAL KILL!")
+		a.broadcast(fmt.Sprintf("%s<white> was eliminated!</white>", a.coloredName(p.Name())))
+		a.checkWinCondition(a.now)
 	}
 }
 
-func (a *Arena) checkWinCondition() {
+// checkWinCondition ends the match if at most one team is left alive.
+func (a *Arena) checkWinCondition(now time.Time) {
 	aliveTeams := 0
 	var winningTeam *team.Team
 
 	for _, t := range a.Teams {
+		a.eliminateAway(t)
 		if t.IsAlive() {
 			aliveTeams++
 			winningTeam = t
 		}
 	}
 
-	if aliveTeams <= 1 && a.State == Playing {
-		a.endGame(winningTeam)
+	if aliveTeams <= 1 && a.State.InGame() {
+		a.endGame(winningTeam, now)
 	}
 }
 
-func (a *Arena) endGame(winningTeam *team.Team) {
-	a.State = Ending
-
-	for _, gen := range a.Generators {
-		gen.Stop()
-	}
-
-	if winningTeam != nil {
-		a.broadcast("<gold>===== GAME OVER! =====</gold>")
-		a.broadcast(fmt.Sprintf("%sTeam %s wins!", winningTeam.Color, winningTeam.ColorName))
-	} else {
-		a.broadcast("<gold>Game ended with no winners!</gold>")
+// rollback restores the arena's world w to the state of its template map by
+// replaying the Journal j and removing all entities left behind by the match.
+// w and j must be read with the arena's mutex held, but rollback must be
+// called without it, as it waits for transactions in w.
+func (a *Arena) rollback(w *world.World, j *Journal) {
+	if w == nil {
+		return
 	}
-
-	time.AfterFunc(10*time.Second, func() {
-		a.reset()
+	n := j.Len()
+	j.Rollback(w)
+	<-w.Exec(func(tx *world.Tx) {
+		for e := range tx.Entities() {
+			if _, ok := e.(*player.Player); !ok {
+				_ = tx.RemoveEntity(e).Close()
+			}
+		}
 	})
+	a.log.Infof("Rolled back %d blocks in arena %s", n, a.Name)
 }
 
-func (a *Arena) reset() {
+// discardInstance closes the arena's world and removes its copy of the
+// template map, so that the next match is played on a fresh copy. All players
+// must have left the world before, as closing it closes all entities in it.
+func (a *Arena) discardInstance() {
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	inst := a.instance
+	a.instance, a.World = nil, nil
+	a.mu.Unlock()
 
-	for _, gen := range a.Generators {
-		gen.Stop()
+	a.Journal.Reset()
+	if inst == nil {
+		return
 	}
+	if err := inst.close(); err != nil {
+		a.log.Errorf("Failed to close map of arena %s: %v", a.Name, err)
+	}
+}
 
-	for _, pd := range a.Players {
-		pd.Player.Teleport(a.Config.LobbySpawn)
+// RecordBlock records the block at pos in the arena's Journal before it is
+// changed, if pos is in the arena's world.
+func (a *Arena) RecordBlock(tx *world.Tx, pos cube.Pos) {
+	if tx.World() != a.World {
+		return
 	}
+	a.Journal.Record(tx, pos)
+}
 
-	a.Players = make(map[string]*PlayerData)
-	a.PlacedBlocks = make(map[cube.Pos]bool)
-	a.State = Waiting
-	a.initTeams()
-	a.initGenerators()
+// RecordItemUse records the blocks that using held on the block at pos may
+// change in the arena's Journal, if a match is running in the arena. Buckets
+// take liquid from pos or put it down at pos or the side clicked, and fire
+// is lit in the same places.
+func (a *Arena) RecordItemUse(tx *world.Tx, held item.Stack, pos cube.Pos, face cube.Face) {
+	switch held.Item().(type) {
+	case item.Bucket, item.FlintAndSteel, item.FireCharge:
+	default:
+		return
+	}
+	if !a.IsPlaying() {
+		return
+	}
+	a.RecordBlock(tx, pos)
+	a.RecordBlock(tx, pos.Side(face))
 }
 
-func (a *Arena) broadcast(message string) {
-	for _, pd := range a.Players {
-		pd.Player.Message(message)
+// Close stops the arena's game loop, closes its world and removes its copy of
+// the template map.
+func (a *Arena) Close() error {
+	close(a.closing)
+
+	a.mu.Lock()
+	inst := a.instance
+	a.instance, a.World = nil, nil
+	a.mu.Unlock()
+
+	if inst == nil {
+		return nil
 	}
+	return inst.close()
 }
 
 func (a *Arena) CanBreakBlock(p *player.Player, pos cube.Pos) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	if a.PlacedBlocks[pos] {
 		return true
 	}
 
-	for _, t := range a.Teams {
-		if t.EggPos == pos && t.EggAlive {
-			pd := a.Players[p.Name()]
-			if pd != nil && pd.Team != nil && pd.Team.Color != t.Color {
-				t.BreakEgg()
-				a.broadcast(fmt.Sprintf("<red>%sTeam %s's egg was destroyed!</red>", t.Color, t.ColorName))
-				return true
-			}
-			return false
-		}
-	}
-
+	// Eggs are destroyed by InteractEgg as soon as they are punched, so they
+	// are never broken the vanilla way.
 	return false
 }
 
-func (a *Arena) TrackPlacedBlock(pos cube.Pos) {
+func (a *Arena) TrackPlacedBlock(p *player.Player, pos cube.Pos) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	a.PlacedBlocks[pos] = true
+	if pd, ok := a.Players[p.Name()]; ok {
+		pd.BlocksPlaced++
+	}
 }
 
+// recordStats adds what a player did in the current match to its stats.
+func (a *Arena) recordStats(pd *PlayerData, won bool) {
+	until := pd.playedUntil
+	if until.IsZero() {
+		until = a.now
+	}
+	r := a.global.Rewards
+	coins := r.Game + pd.Kills*r.Kill + pd.EggsDestroyed*r.Egg
+	if won {
+		coins += r.Win
+	}
+	if coins > 0 {
+		a.exec(pd, func(p *player.Player) {
+			p.Message(fmt.Sprintf("§6+%d coins", coins))
+		})
+	}
+	a.stats.Record(pd.ID, pd.Player.Name(), stats.Match{
+		Kills:         pd.Kills,
+		Assists:       pd.Assists,
+		Deaths:        pd.Deaths,
+		EggsDestroyed: pd.EggsDestroyed,
+		BlocksPlaced:  pd.BlocksPlaced,
+		Won:           won,
+		TimePlayed:    until.Sub(pd.playedFrom),
+		Time:          a.now,
+		Coins:         coins,
+	})
+}
+
+// rate updates the ratings of all players of the match that just ended from
+// the places their teams finished in, and tells every player how its rating
+// changed. Teams are placed in the order of the standings. Players that left
+// the match are placed last.
+func (a *Arena) rate() {
+	var placings []stats.Placing
+	place := make(map[*team.Team]int)
+	for _, st := range a.standings() {
+		pd := a.Players[st.Player]
+		i, ok := place[pd.Team]
+		if !ok {
+			i = len(placings)
+			place[pd.Team] = i
+			placings = append(placings, stats.Placing{Place: i + 1})
+		}
+		placings[i].Players = append(placings[i].Players, pd.ID)
+	}
+	last := len(placings) + 1
+	for _, id := range a.leavers {
+		placings = append(placings, stats.Placing{Players: []string{id}, Place: last})
+	}
+
+	changes := a.stats.Rate(placings)
+	for _, pd := range a.Players {
+		change, ok := changes[pd.ID]
+		if !ok {
+			continue
+		}
+		rating := a.stats.GetStats(pd.ID, pd.Player.Name()).Rating
+		a.exec(pd, func(p *player.Player) {
+			p.Message(fmt.Sprintf("§7Rating: §e%.0f §7(%s)", rating, ratingChange(change)))
+		})
+	}
+}
+
+// ratingChange formats a change in rating, such as "§a+12" or "§c-8".
+func ratingChange(change float64) string {
+	if change = math.Round(change); change < 0 {
+		return fmt.Sprintf("§c%.0f", change)
+	}
+	return fmt.Sprintf("§a+%.0f", change)
+}
+
+// IsPlaying checks if a match is being played in the arena.
 func (a *Arena) IsPlaying() bool {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	return a.State == Playing
+	return a.State.InGame()
+}
+
+// Status returns the current state of the arena and its amount of players,
+// not counting spectators.
+func (a *Arena) Status() (GameState, int) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.State, a.playerCount()
 }
 
 func (a *Arena) OpenShop(p *player.Player) {
@@ -409,31 +536,64 @@ func (a *Arena) OpenShop(p *player.Player) {
 		p.Message("§c✗ Error opening shop")
 		return
 	}
-
-	resources := ""
-	if pd.Resources != nil {
-		if iron, ok := pd.Resources["iron"]; ok {
-			resources += fmt.Sprintf("§6Iron: §f%d  ", iron)
-		}
-		if gold, ok := pd.Resources["gold"]; ok {
-			resources += fmt.Sprintf("§eGold: §f%d  ", gold)
-		}
-		if diamond, ok := pd.Resources["diamond"]; ok {
-			resources += fmt.Sprintf("§bDiamond: §f%d", diamond)
+	c := shop.Customer{Wallet: shop.InventoryWallet{Inv: p.Inventory()}}
+	if t := pd.Team; t != nil {
+		c.Tint = func(s item.Stack) item.Stack {
+			a.mu.RLock()
+			sharpness, protection := t.Tier(team.Sharpness), t.Tier(team.Protection)
+			a.mu.RUnlock()
+			s, _ = enchantStack(t.Color.Tint(s), sharpness, protection)
+			return s
 		}
 	}
+	name := p.Name()
+	c.Bought = func(o shop.Offer) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.logEvent(match.Event{Type: match.Purchase, Player: name, Item: o.Name, Price: o.Price, Currency: o.Currency})
+	}
+	a.shop.Open(p, c)
+}
 
-	menu := form.NewMenu(shopMenu{
-		a: a,
-		Iron:   form.NewButton("§cIron Helmet\n§7Cost: 10 Iron", ""),
-		Sword:  form.NewButton("§f⚔ Diamond Sword\n§7Cost: 5 Diamond", ""),
-		Shield: form.NewButton("§9Shield\n§7Cost: 5 Gold + 10 Iron", ""),
-		Close:  form.NewButton("§8Close", ""),
-	}, "§6EggWars Shop")
-
-	menu = menu.WithBody(fmt.Sprintf("Your Resources:\n%s\n\nSelect an item:", resources))
+// GeneratorAt returns the generator whose block is at pos, if any.
+func (a *Arena) GeneratorAt(pos cube.Pos) (*generator.Generator, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, g := range a.Generators {
+		if g.Pos == pos {
+			return g, true
+		}
+	}
+	return nil, false
+}
 
-	p.SendForm(menu)
+// OpenGeneratorUpgrade opens the upgrade form of g for p. Players may only
+// upgrade shared generators and those of their own team.
+func (a *Arena) OpenGeneratorUpgrade(p *player.Player, g *generator.Generator) {
+	pd := a.GetPlayerData(p.Name())
+	if pd == nil || !pd.IsAlive || !a.IsPlaying() {
+		return
+	}
+	if g.Team != "" && (pd.Team == nil || string(pd.Team.Color) != g.Team) {
+		p.Message("§c✗ You can only upgrade your own team's generators.")
+		return
+	}
+	name := p.Name()
+	g.OpenUpgrade(p, generator.Buyer{
+		Wallet: shop.InventoryWallet{Inv: p.Inventory()},
+		Open: func() (time.Time, bool) {
+			// The form may be submitted after the match ended, or even
+			// during the next match, which has generators of its own.
+			a.mu.RLock()
+			defer a.mu.RUnlock()
+			return a.now, a.State.InGame() && slices.Contains(a.Generators, g)
+		},
+		Upgraded: func(level int) {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			a.logEvent(match.Event{Type: match.Upgrade, Player: name, Team: g.Team, Item: string(g.ResourceType), Level: level})
+		},
+	})
 }
 
 func (a *Arena) GetPlayerData(name string) *PlayerData {
@@ -0,0 +1,44 @@
+package arena
+
+import (
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// teamArmourKey is the key of the value that marks the team armour players
+// get every life.
+const teamArmourKey = "eggwars:team_armour"
+
+// giveTeamArmour puts leather armour dyed in the colour c in every armour slot
+// of p that is empty. Armour bought in the shop is left alone.
+func giveTeamArmour(p *player.Player, c team.Color) {
+	leather := item.ArmourTierLeather{}
+	piece := func(current item.Stack, it world.Item) item.Stack {
+		if !current.Empty() {
+			return current
+		}
+		return c.Tint(item.NewStack(it, 1)).WithValue(teamArmourKey, true)
+	}
+	a := p.Armour()
+	a.Set(
+		piece(a.Helmet(), item.Helmet{Tier: leather}),
+		piece(a.Chestplate(), item.Chestplate{Tier: leather}),
+		piece(a.Leggings(), item.Leggings{Tier: leather}),
+		piece(a.Boots(), item.Boots{Tier: leather}),
+	)
+}
+
+// IsTeamArmour checks if s is a piece of the team armour, which may not be
+// dropped.
+func IsTeamArmour(s item.Stack) bool {
+	_, ok := s.Value(teamArmourKey)
+	return ok
+}
+
+// nameTag returns the name tag of a player in the team passed.
+func nameTag(t *team.Team, name string) string {
+	return t.Color.Code() + name
+}
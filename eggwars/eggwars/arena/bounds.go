@@ -0,0 +1,57 @@
+package arena
+
+import (
+	"math"
+
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// pushBack is the strength with which players leaving the arena's bounds are
+// pushed back in.
+const pushBack = 0.6
+
+// HandleMove keeps p inside the arena while it moves to newPos. Players that
+// fall below the arena's void level die at once, which counts as a kill for
+// whoever hit them last. Players leaving the bounds are pushed back in.
+// HandleMove returns false if the movement must be cancelled.
+func (a *Arena) HandleMove(p *player.Player, newPos mgl64.Vec3) bool {
+	a.mu.RLock()
+	pd, ok := a.Players[p.Name()]
+	active := ok && a.World != nil && p.Tx().World() == a.World && (a.State.InGame() || a.State == Ending)
+	alive := ok && a.State.InGame() && pd.IsAlive && !pd.Spectator
+	cfg, spawn := a.Config, a.spectatorSpawn()
+	trapped := alive && a.nearTrap(pd, newPos)
+	a.mu.RUnlock()
+
+	if !active {
+		return true
+	}
+	if trapped {
+		a.triggerTraps(p, newPos)
+	}
+	if newPos.Y() < cfg.VoidY {
+		if alive {
+			p.Hurt(math.MaxFloat32, entity.VoidDamageSource{})
+		} else {
+			p.Teleport(spawn)
+		}
+		return false
+	}
+	if !cfg.Bounded() {
+		return true
+	}
+	box := cfg.Box()
+	if newPos.X() >= box.Min().X() && newPos.X() <= box.Max().X() && newPos.Z() >= box.Min().Z() && newPos.Z() <= box.Max().Z() {
+		return true
+	}
+	dir := box.Min().Add(box.Max()).Mul(0.5).Sub(newPos)
+	dir[1] = 0
+	if dir.Len() > 0 {
+		dir = dir.Normalize()
+	}
+	p.SetVelocity(dir.Mul(pushBack).Add(mgl64.Vec3{0, 0.3}))
+	p.SendTip("§cYou can't leave the arena!")
+	return false
+}
@@ -0,0 +1,181 @@
+package arena
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// hit is a hit a player took from another player.
+type hit struct {
+	attacker string
+	at       time.Time
+}
+
+// HandleHurt tags the player hurt as being in combat with the player that
+// caused the damage, if any. It returns false if the damage must be
+// cancelled because it was dealt by a teammate.
+func (a *Arena) HandleHurt(victim *player.Player, src world.DamageSource) bool {
+	attacker, ok := damager(src)
+	if !ok {
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.friendlyFire(victim.Name(), attacker.Name()) {
+		return false
+	}
+	a.tag(victim.Name(), attacker.Name())
+	return true
+}
+
+// HandleAttack tags the player attacked as being in combat with its attacker.
+// Unlike HandleHurt, it also catches hits that only dealt knockback. It
+// returns false if the attack must be cancelled because the players are
+// teammates, or because a shopkeeper was hit, which opens what it offers.
+func (a *Arena) HandleAttack(attacker *player.Player, e world.Entity) bool {
+	if a.InteractNPC(attacker, e) {
+		return false
+	}
+	victim, ok := e.(*player.Player)
+	if !ok {
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.friendlyFire(victim.Name(), attacker.Name()) {
+		return false
+	}
+	a.tag(victim.Name(), attacker.Name())
+	return true
+}
+
+// friendlyFire checks if a hit of attacker on victim must be prevented: the
+// players are teammates and the arena does not allow friendly fire.
+func (a *Arena) friendlyFire(victim, attacker string) bool {
+	if a.Config.FriendlyFire || victim == attacker {
+		return false
+	}
+	v, ok := a.Players[victim]
+	at, ok2 := a.Players[attacker]
+	return ok && ok2 && v.Team != nil && v.Team == at.Team
+}
+
+// tag records a hit of attacker on victim. Hits are only recorded between
+// living players of different teams while a match is running.
+func (a *Arena) tag(victim, attacker string) {
+	if !a.State.InGame() || victim == attacker {
+		return
+	}
+	v, ok := a.Players[victim]
+	if !ok || !v.IsAlive {
+		return
+	}
+	at, ok := a.Players[attacker]
+	if !ok || !at.IsAlive || (v.Team != nil && v.Team == at.Team) {
+		return
+	}
+
+	hits := v.hits[:0]
+	for _, h := range v.hits {
+		// Only the last hit of every attacker is kept.
+		if h.attacker != attacker {
+			hits = append(hits, h)
+		}
+	}
+	v.hits = append(hits, hit{attacker: attacker, at: a.now})
+}
+
+// attackers returns the player credited with killing pd and the players that
+// assisted. The killer is the player that dealt the final damage or, if the
+// player died to anything else, the player that hit it last within the combat
+// window. The killer is empty if nobody is credited with the kill.
+func (a *Arena) attackers(pd *PlayerData, src world.DamageSource) (killer string, assists []string) {
+	if p, ok := damager(src); ok {
+		killer = p.Name()
+	}
+	window := seconds(a.global.Combat.TagWindow)
+	for i := len(pd.hits) - 1; i >= 0; i-- {
+		h := pd.hits[i]
+		if a.now.Sub(h.at) > window {
+			break
+		}
+		if killer == "" {
+			killer = h.attacker
+		} else if h.attacker != killer {
+			assists = append(assists, h.attacker)
+		}
+	}
+	return killer, assists
+}
+
+// damager returns the player that dealt the damage of src, either directly or
+// by firing a projectile.
+func damager(src world.DamageSource) (*player.Player, bool) {
+	switch src := src.(type) {
+	case entity.AttackDamageSource:
+		p, ok := src.Attacker.(*player.Player)
+		return p, ok
+	case entity.ProjectileDamageSource:
+		p, ok := src.Owner.(*player.Player)
+		return p, ok
+	}
+	return nil, false
+}
+
+// deathMessage returns the message broadcast when victim dies to src. killer
+// may be empty if nobody is credited with the kill.
+func (a *Arena) deathMessage(victim, killer string, assists []string, src world.DamageSource) string {
+	v := a.coloredName(victim)
+	if killer == "" {
+		switch src.(type) {
+		case entity.VoidDamageSource:
+			return v + "§7 fell into the void."
+		case entity.FallDamageSource:
+			return v + "§7 hit the ground too hard."
+		case block.FireDamageSource, block.LavaDamageSource:
+			return v + "§7 burned to death."
+		case entity.ExplosionDamageSource:
+			return v + "§7 blew up."
+		}
+		return v + "§7 died."
+	}
+
+	k := a.coloredName(killer)
+	var msg string
+	switch src.(type) {
+	case entity.VoidDamageSource:
+		msg = fmt.Sprintf("%s§7 was knocked into the void by %s§7.", v, k)
+	case entity.FallDamageSource:
+		msg = fmt.Sprintf("%s§7 was knocked off a cliff by %s§7.", v, k)
+	case block.FireDamageSource, block.LavaDamageSource:
+		msg = fmt.Sprintf("%s§7 was burnt to a crisp while fighting %s§7.", v, k)
+	case entity.ProjectileDamageSource:
+		msg = fmt.Sprintf("%s§7 was shot by %s§7.", v, k)
+	case entity.ExplosionDamageSource:
+		msg = fmt.Sprintf("%s§7 was blown up by %s§7.", v, k)
+	default:
+		msg = fmt.Sprintf("%s§7 was slain by %s§7.", v, k)
+	}
+	if len(assists) > 0 {
+		names := make([]string, len(assists))
+		for i, name := range assists {
+			names[i] = a.coloredName(name)
+		}
+		msg += " §8(assist: " + strings.Join(names, "§8, ") + "§8)"
+	}
+	return msg
+}
+
+// coloredName returns the name of a player in the colour of its team.
+func (a *Arena) coloredName(name string) string {
+	if pd, ok := a.Players[name]; ok && pd.Team != nil {
+		return pd.Team.Color.Code() + name
+	}
+	return "§7" + name
+}
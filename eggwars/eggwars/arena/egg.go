@@ -0,0 +1,178 @@
+package arena
+
+import (
+	"fmt"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/match"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/title"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/particle"
+	"github.com/df-mc/dragonfly/server/world/sound"
+)
+
+// placeEggs places a dragon egg at the egg of every team in the match and
+// clears the eggs of teams that are out from the start. The arena's mutex
+// must be held.
+func (a *Arena) placeEggs() {
+	w := a.World
+	if w == nil {
+		return
+	}
+	eggs := make(map[cube.Pos]bool, len(a.Teams))
+	for _, t := range a.Teams {
+		eggs[t.EggPos] = t.EggAlive
+	}
+	a.later(func() {
+		w.Exec(func(tx *world.Tx) {
+			for pos, alive := range eggs {
+				a.Journal.Record(tx, pos)
+				if alive {
+					tx.SetBlock(pos, block.DragonEgg{}, nil)
+				} else {
+					tx.SetBlock(pos, nil, nil)
+				}
+			}
+		})
+	})
+}
+
+// eggAt returns the team whose egg is at pos, if the egg is still alive. The
+// arena's mutex must be held.
+func (a *Arena) eggAt(pos cube.Pos) *team.Team {
+	for _, t := range a.Teams {
+		if t.EggAlive && t.EggPos == pos {
+			return t
+		}
+	}
+	return nil
+}
+
+// InteractEgg handles p punching or using the block at pos. It returns true if
+// the block is an egg, in which case the interaction must be cancelled: eggs
+// are never broken or teleported the vanilla way. If the egg belongs to
+// another team, p destroys it.
+func (a *Arena) InteractEgg(p *player.Player, pos cube.Pos) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.World == nil || p.Tx().World() != a.World {
+		return false
+	}
+	t := a.eggAt(pos)
+	if t == nil {
+		return false
+	}
+	pd, ok := a.Players[p.Name()]
+	if !ok || !a.State.InGame() || !pd.IsAlive || pd.Spectator || pd.Team == nil {
+		return true
+	}
+	if pd.Team == t {
+		p.Message("§c✗ You can't destroy your own egg!")
+		return true
+	}
+	a.destroyEgg(t, pd)
+	return true
+}
+
+// destroyEgg destroys the egg of t on behalf of pd. The arena's mutex must be
+// held.
+func (a *Arena) destroyEgg(t *team.Team, pd *PlayerData) {
+	a.breakEgg(t)
+	pd.EggsDestroyed++
+	a.logEvent(match.Event{Type: match.EggDestroy, Player: pd.Player.Name(), Team: string(t.Color)})
+	a.broadcast(fmt.Sprintf("<red>%s§c's egg was destroyed by %s§c!</red>", t.Title(), a.coloredName(pd.Player.Name())))
+
+	for _, name := range t.Players {
+		if victim, ok := a.Players[name]; ok {
+			a.exec(victim, func(p *player.Player) {
+				p.SendTitle(title.New("§c§lEGG DESTROYED!").WithSubtitle("§7You will no longer respawn!"))
+				p.PlaySound(sound.GhastWarning{})
+			})
+		}
+	}
+	// A team whose players are all disconnected is out once its egg is gone.
+	a.checkWinCondition(a.now)
+}
+
+// breakEgg marks the egg of t as broken and removes it from the world with a
+// lightning strike and an explosion. The arena's mutex must be held.
+func (a *Arena) breakEgg(t *team.Team) {
+	if !t.EggAlive {
+		return
+	}
+	t.BreakEgg()
+
+	w, pos := a.World, t.EggPos
+	if w == nil {
+		return
+	}
+	a.later(func() {
+		w.Exec(func(tx *world.Tx) {
+			a.Journal.Record(tx, pos)
+			tx.SetBlock(pos, nil, nil)
+			tx.AddParticle(pos.Vec3Centre(), particle.HugeExplosion{})
+			tx.AddEntity(entity.NewLightningWithDamage(world.EntitySpawnOpts{Position: pos.Vec3Centre()}, 0, false, 0))
+		})
+	})
+}
+
+// CanPlaceBlock checks if p may place a block at pos. During a match, blocks
+// may only be placed within the arena's bounds and below its build height,
+// and not within the protection radius of eggs and team spawns.
+func (a *Arena) CanPlaceBlock(p *player.Player, pos cube.Pos) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if msg := a.buildProblem(pos); msg != "" {
+		p.Message(msg)
+		return false
+	}
+	return true
+}
+
+// buildProblem returns the message telling players why no block may be placed
+// at pos, or an empty string if one may. The arena's mutex must be held.
+func (a *Arena) buildProblem(pos cube.Pos) string {
+	if !a.State.InGame() {
+		return ""
+	}
+	if !a.Config.InBounds(pos) {
+		return "§c✗ You can't build outside the arena!"
+	}
+	if h := a.Config.BuildHeight; h != 0 && pos[1] > h {
+		return fmt.Sprintf("§c✗ You can't build higher than Y %d!", h)
+	}
+	prot := a.Config.Protection
+	for _, t := range a.Teams {
+		if t.EggAlive && within(pos, t.EggPos, prot.EggRadius) {
+			return "§c✗ You can't build this close to an egg!"
+		}
+		if within(pos, cube.PosFromVec3(t.Spawn), prot.SpawnRadius) {
+			return "§c✗ You can't build this close to a spawn!"
+		}
+	}
+	return ""
+}
+
+// within checks if pos is at most radius blocks away from centre along every
+// axis. It is always false for a radius of 0 or less.
+func within(pos, centre cube.Pos, radius int) bool {
+	if radius <= 0 {
+		return false
+	}
+	d := pos.Sub(centre)
+	return abs(d[0]) <= radius && abs(d[1]) <= radius && abs(d[2]) <= radius
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,55 @@
+package arena
+
+import (
+	"slices"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// worldHandler is the world.Handler of an arena's instance. It records every
+// block changed by the world itself in the arena's Journal.
+type worldHandler struct {
+	world.NopHandler
+	a *Arena
+}
+
+func (h worldHandler) HandleLiquidFlow(ctx *world.Context, from, into cube.Pos, liquid world.Liquid, replaced world.Block) {
+	h.a.Journal.Record(ctx.Val(), into)
+}
+
+func (h worldHandler) HandleLiquidDecay(ctx *world.Context, pos cube.Pos, before, after world.Liquid) {
+	h.a.Journal.Record(ctx.Val(), pos)
+}
+
+func (h worldHandler) HandleLiquidHarden(ctx *world.Context, hardenedPos cube.Pos, liquidHardened, otherLiquid, newBlock world.Block) {
+	h.a.Journal.Record(ctx.Val(), hardenedPos)
+}
+
+func (h worldHandler) HandleFireSpread(ctx *world.Context, from, to cube.Pos) {
+	h.a.Journal.Record(ctx.Val(), to)
+}
+
+func (h worldHandler) HandleBlockBurn(ctx *world.Context, pos cube.Pos) {
+	h.a.Journal.Record(ctx.Val(), pos)
+}
+
+func (h worldHandler) HandleExplosion(ctx *world.Context, position mgl64.Vec3, entities *[]world.Entity, blocks *[]cube.Pos, itemDropChance *float64, spawnFire *bool) {
+	// Shopkeepers must stay where they are.
+	h.a.mu.RLock()
+	*entities = slices.DeleteFunc(*entities, func(e world.Entity) bool {
+		_, ok := h.a.npc(e)
+		return ok
+	})
+	h.a.mu.RUnlock()
+	for _, pos := range *blocks {
+		h.a.Journal.Record(ctx.Val(), pos)
+	}
+	if *spawnFire {
+		// Fire may be placed on top of any of the destroyed blocks.
+		for _, pos := range *blocks {
+			h.a.Journal.Record(ctx.Val(), pos.Side(cube.FaceUp))
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package arena
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/mcdb"
+)
+
+// instance is a disposable copy of an arena's template map. Every arena plays
+// on its own instance so that several arenas, even ones sharing a template, can
+// run at the same time without affecting each other or the template itself.
+// An arena opens a fresh instance for every match and closes and discards it
+// on reset. If config.Config.ReuseInstances is set, the instance is instead
+// kept for later matches and restored by rolling back the arena's Journal,
+// and it is only discarded when the arena is closed.
+type instance struct {
+	dir string
+	w   *world.World
+}
+
+// openInstance copies the template world at templateDir to a new directory
+// inside instancesDir and opens a world from that copy.
+func openInstance(name, templateDir, instancesDir string, entities world.EntityRegistry) (*instance, error) {
+	if _, err := os.Stat(filepath.Join(templateDir, "level.dat")); err != nil {
+		return nil, fmt.Errorf("template %s is not a world: %w", templateDir, err)
+	}
+	if err := os.MkdirAll(instancesDir, 0777); err != nil {
+		return nil, fmt.Errorf("create instances directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(instancesDir, name+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("create instance directory: %w", err)
+	}
+	if err := copyDir(templateDir, dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("copy template %s: %w", templateDir, err)
+	}
+
+	log := slog.Default().With("arena", name)
+	db, err := mcdb.Config{Log: log}.Open(dir)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("open instance: %w", err)
+	}
+
+	w := world.Config{
+		Log:          log,
+		Provider:     db,
+		Entities:     entities,
+		SaveInterval: -1,
+	}.New()
+	return &instance{dir: dir, w: w}, nil
+}
+
+// close closes the instance's world and removes its copy of the template from
+// disk. The instance must not be used after a call to close.
+func (i *instance) close() error {
+	if err := i.w.Close(); err != nil {
+		return fmt.Errorf("close instance world: %w", err)
+	}
+	if err := os.RemoveAll(i.dir); err != nil {
+		return fmt.Errorf("remove instance directory: %w", err)
+	}
+	return nil
+}
+
+// copyDir recursively copies the directory src to dst, creating directories in
+// dst as needed.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0777)
+		}
+		// LevelDB's lock file belongs to whoever has the template open, it
+		// must not be carried over to the copy.
+		if d.Name() == "LOCK" {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies the contents of the file at src to a new file at dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// OpenCopy opens a disposable copy of the template map at templateDir, such as
+// for setting up an arena on it without changing the template. The function
+// returned closes the world and removes the copy.
+func OpenCopy(name, templateDir, instancesDir string, entities world.EntityRegistry) (*world.World, func() error, error) {
+	inst, err := openInstance(name, templateDir, instancesDir, entities)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inst.w, inst.close, nil
+}
@@ -0,0 +1,97 @@
+package arena
+
+import (
+	"sync"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// rollbackBatchSize is the amount of journal entries restored per transaction
+// when rolling back, so that a large journal does not stall the world.
+const rollbackBatchSize = 512
+
+// journalEntry holds the original state of a single block position.
+type journalEntry struct {
+	pos    cube.Pos
+	b      world.Block
+	nbt    map[string]any
+	liquid world.Liquid
+}
+
+// Journal records the original state of every block position that changes
+// during a match, so that the map can be restored to exactly that state
+// afterwards. Only the first change of each position is recorded.
+type Journal struct {
+	mu      sync.Mutex
+	seen    map[cube.Pos]struct{}
+	entries []journalEntry
+}
+
+// NewJournal returns an empty Journal.
+func NewJournal() *Journal {
+	return &Journal{seen: make(map[cube.Pos]struct{})}
+}
+
+// Record records the current block at pos if the position has not yet been
+// recorded. Record must be called before the block at pos changes.
+func (j *Journal) Record(tx *world.Tx, pos cube.Pos) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, ok := j.seen[pos]; ok {
+		return
+	}
+	j.seen[pos] = struct{}{}
+
+	e := journalEntry{pos: pos, b: tx.Block(pos)}
+	if nbter, ok := e.b.(world.NBTer); ok {
+		// Blocks such as chests hold pointers to their contents, so the
+		// block entity data is stored separately to restore it as it was.
+		e.nbt = nbter.EncodeNBT()
+	}
+	if l, ok := tx.Liquid(pos); ok {
+		e.liquid = l
+	}
+	j.entries = append(j.entries, e)
+}
+
+// Len returns the amount of positions recorded in the Journal.
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.entries)
+}
+
+// Reset clears the Journal without restoring anything.
+func (j *Journal) Reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries, j.seen = nil, make(map[cube.Pos]struct{})
+}
+
+// Rollback restores all recorded positions in w in reverse order and clears
+// the Journal. Entries are restored in batches of rollbackBatchSize, each in a
+// transaction of its own. Rollback blocks until all entries are restored.
+func (j *Journal) Rollback(w *world.World) {
+	j.mu.Lock()
+	entries := j.entries
+	j.entries, j.seen = nil, make(map[cube.Pos]struct{})
+	j.mu.Unlock()
+
+	opts := &world.SetOpts{DisableBlockUpdates: true, DisableLiquidDisplacement: true}
+	for end := len(entries); end > 0; end -= rollbackBatchSize {
+		batch := entries[max(0, end-rollbackBatchSize):end]
+		<-w.Exec(func(tx *world.Tx) {
+			for i := len(batch) - 1; i >= 0; i-- {
+				e := batch[i]
+				b := e.b
+				if e.nbt != nil {
+					b = b.(world.NBTer).DecodeNBT(e.nbt).(world.Block)
+				}
+				tx.SetBlock(e.pos, b, opts)
+				tx.SetLiquid(e.pos, e.liquid)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package arena
+
+import (
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/utility"
+
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// TickInterval is the interval at which the game loop of an arena ticks.
+const TickInterval = time.Second / 4
+
+// run runs the game loop of the arena until the arena is closed.
+func (a *Arena) run() {
+	ticker := time.NewTicker(TickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			a.Tick(now)
+		case <-a.closing:
+			return
+		}
+	}
+}
+
+// Tick advances the arena's state to the time passed. It is called by the
+// game loop of the arena, but may also be called directly with a fake time
+// to drive the arena manually.
+// All state changes happen with the arena's mutex held. Work that could block,
+// such as transactions on players or worlds, is queued by the state and run
+// after the mutex is released.
+func (a *Arena) Tick(now time.Time) {
+	a.mu.Lock()
+	a.now = now
+	if p := phases[a.State]; p.tick != nil {
+		p.tick(a, now)
+	}
+	a.updateScoreboards(now)
+	jobs := a.jobs
+	a.jobs = nil
+	a.mu.Unlock()
+
+	for _, job := range jobs {
+		job()
+	}
+}
+
+// later queues f to be run by the game loop once the arena's mutex is
+// released. The arena's mutex must be held when calling later.
+func (a *Arena) later(f func()) {
+	a.jobs = append(a.jobs, f)
+}
+
+// exec queues f to be run in a transaction of the player's current world. The
+// arena's mutex must be held when calling exec.
+func (a *Arena) exec(pd *PlayerData, f func(p *player.Player)) {
+	h := pd.Player.H()
+	a.later(func() {
+		h.ExecWorld(func(tx *world.Tx, e world.Entity) {
+			f(e.(*player.Player))
+		})
+	})
+}
+
+// broadcast sends a message to all players in the arena. The arena's mutex
+// must be held when calling broadcast.
+func (a *Arena) broadcast(message string) {
+	for _, pd := range a.Players {
+		a.exec(pd, func(p *player.Player) {
+			p.Message(message)
+		})
+	}
+}
+
+// openInstance opens the arena's world from its template map. It is run as a
+// job of the game loop.
+func (a *Arena) openInstance() {
+	inst, err := openInstance(a.Name, a.global.TemplateDir(a.Config), a.global.InstancesDir, utility.Entities(a.Lobby.EntityRegistry()))
+	if err == nil {
+		inst.w.Handle(worldHandler{a: a})
+	} else {
+		a.log.Errorf("Failed to open map for arena %s: %v", a.Name, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.opening = false
+	if err != nil {
+		a.instanceErr = err
+		return
+	}
+	a.instance, a.World = inst, inst.w
+}
+
+// tickGenerators ticks all generators of the arena in its world.
+func (a *Arena) tickGenerators(now time.Time) {
+	gens, w := a.Generators, a.World
+	a.later(func() {
+		<-w.Exec(func(tx *world.Tx) {
+			for _, g := range gens {
+				g.Tick(tx, now)
+			}
+		})
+	})
+}
+
+// closeGenerators removes the holograms of all generators of the arena.
+func (a *Arena) closeGenerators() {
+	gens, w := a.Generators, a.World
+	a.Generators = nil
+	a.later(func() {
+		<-w.Exec(func(tx *world.Tx) {
+			for _, g := range gens {
+				g.Close(tx)
+			}
+		})
+	})
+}
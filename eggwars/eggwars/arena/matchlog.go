@@ -0,0 +1,198 @@
+package arena
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/match"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/form"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// logEvent adds e to the log of the running match, if any. Events without a
+// time happened at the time of the last tick. The arena's mutex must be held.
+func (a *Arena) logEvent(e match.Event) {
+	if a.match == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = a.now
+	}
+	a.match.Add(e)
+}
+
+// startLog starts the log of a match starting at the time passed, recording
+// the players that joined it and the teams they were put in. The arena's
+// mutex must be held.
+func (a *Arena) startLog(now time.Time) {
+	a.match = match.New(a.Name, now)
+
+	pds := make([]*PlayerData, 0, len(a.Players))
+	for _, pd := range a.Players {
+		pds = append(pds, pd)
+	}
+	sort.Slice(pds, func(i, j int) bool {
+		return pds[i].joinedAt.Before(pds[j].joinedAt)
+	})
+	for _, pd := range pds {
+		a.logEvent(match.Event{Time: pd.joinedAt, Type: match.Join, Player: pd.Player.Name()})
+	}
+	a.logEvent(match.Event{Type: match.Start, Arena: a.Name, Mode: a.Config.Mode()})
+	for _, pd := range pds {
+		if pd.Team != nil {
+			a.logEvent(match.Event{Type: match.TeamAssign, Player: pd.Player.Name(), Team: string(pd.Team.Color)})
+		}
+	}
+}
+
+// logKill logs the death of victim to src. killer is the name of the player
+// credited with the kill, which may be empty. The arena's mutex must be held.
+func (a *Arena) logKill(victim *player.Player, killer string, src world.DamageSource, final bool) {
+	e := match.Event{Type: match.Kill, Player: killer, Target: victim.Name(), Final: final, Weapon: cause(src)}
+	if pd, ok := a.Players[victim.Name()]; ok && pd.Team != nil {
+		e.Team = string(pd.Team.Color)
+	}
+	if k, ok := damager(src); ok {
+		if src, ok := src.(entity.ProjectileDamageSource); ok {
+			e.Weapon = strings.TrimPrefix(src.Projectile.H().Type().EncodeEntity(), "minecraft:")
+		} else {
+			held, _ := k.HeldItems()
+			e.Weapon = itemName(held)
+		}
+		e.Distance = math.Round(victim.Position().Sub(k.Position()).Len()*10) / 10
+	}
+	a.logEvent(e)
+}
+
+// cause returns the name of what dealt the damage of src, used as the weapon
+// of kills that no item was used for.
+func cause(src world.DamageSource) string {
+	switch src.(type) {
+	case entity.VoidDamageSource:
+		return "void"
+	case entity.FallDamageSource:
+		return "fall"
+	case block.FireDamageSource, block.LavaDamageSource:
+		return "fire"
+	case entity.ExplosionDamageSource:
+		return "explosion"
+	case entity.ProjectileDamageSource:
+		return "projectile"
+	}
+	return "other"
+}
+
+// itemName returns the name of the item of s, such as "diamond_sword", or
+// "fist" if s is empty.
+func itemName(s item.Stack) string {
+	if s.Empty() {
+		return "fist"
+	}
+	name, _ := s.Item().EncodeItem()
+	return strings.TrimPrefix(name, "minecraft:")
+}
+
+// standings returns the standings of all players that played in the match,
+// best first. Players of the winning team come first, followed by the others
+// in the reverse order they were eliminated in. The arena's mutex must be
+// held.
+func (a *Arena) standings() []match.Standing {
+	var pds []*PlayerData
+	for _, pd := range a.Players {
+		if pd.Team != nil {
+			pds = append(pds, pd)
+		}
+	}
+	until := func(pd *PlayerData) time.Time {
+		if pd.playedUntil.IsZero() {
+			return a.now
+		}
+		return pd.playedUntil
+	}
+	standing := func(pd *PlayerData) match.Standing {
+		return match.Standing{
+			Player:  pd.Player.Name(),
+			Team:    string(pd.Team.Color),
+			Kills:   pd.Kills,
+			Assists: pd.Assists,
+			Deaths:  pd.Deaths,
+			Eggs:    pd.EggsDestroyed,
+			Won:     a.winner != nil && pd.Team == a.winner,
+		}
+	}
+	sort.Slice(pds, func(i, j int) bool {
+		si, sj := standing(pds[i]), standing(pds[j])
+		if si.Won != sj.Won {
+			return si.Won
+		}
+		if ui, uj := until(pds[i]), until(pds[j]); !ui.Equal(uj) {
+			return ui.After(uj)
+		}
+		if si.Score() != sj.Score() {
+			return si.Score() > sj.Score()
+		}
+		return si.Player < sj.Player
+	})
+
+	standings := make([]match.Standing, len(pds))
+	for i, pd := range pds {
+		standings[i] = standing(pd)
+		standings[i].Place = i + 1
+	}
+	return standings
+}
+
+// endLog logs the end of the match with its final standings, shows every
+// player a summary of the match and saves its log. The arena's mutex must be
+// held.
+func (a *Arena) endLog() {
+	l := a.match
+	if l == nil {
+		return
+	}
+	e := match.Event{Type: match.End, Standings: a.standings()}
+	if a.winner != nil {
+		e.Winner = string(a.winner.Color)
+	}
+	a.logEvent(e)
+	a.match = nil
+
+	s := l.Summary()
+	highlights := s.Lines(a.coloredName, teamTitle)
+	for _, line := range highlights {
+		a.broadcast(line)
+	}
+	body := match.Text(highlights, []string{""}, s.StandingLines(a.coloredName))
+	for _, pd := range a.Players {
+		a.exec(pd, func(p *player.Player) {
+			p.SendForm(form.NewMenu(summaryMenu{Close: form.NewButton("Close", "")}, "§6Match Summary").WithBody(body))
+		})
+	}
+
+	dir := a.global.MatchesDir
+	a.later(func() {
+		if err := l.Save(dir); err != nil {
+			a.log.Errorf("Failed to save log of match %s: %v", l.ID, err)
+		}
+	})
+}
+
+// teamTitle returns the title of the team with the colour passed.
+func teamTitle(colour string) string {
+	return team.Color(colour).Title()
+}
+
+// summaryMenu is the form showing the summary of a match once it is over.
+type summaryMenu struct {
+	Close form.Button
+}
+
+func (summaryMenu) Submit(form.Submitter, form.Button, *world.Tx) {}
@@ -0,0 +1,182 @@
+package arena
+
+import (
+	"fmt"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/generator"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/npc"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/dialogue"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// maxDialogueButtons is the most buttons a dialogue may hold.
+const maxDialogueButtons = 6
+
+// loadNPCSkin loads the skin configured for shopkeepers, if any.
+func (a *Arena) loadNPCSkin() {
+	path := a.global.NPCs.Skin
+	if path == "" {
+		return
+	}
+	s, err := npc.LoadSkin(path)
+	if err != nil {
+		a.log.Errorf("Arena %s: failed to load shopkeeper skin, using plain skins: %v", a.Name, err)
+		return
+	}
+	a.npcSkin = &s
+}
+
+// spawnNPCs spawns the shopkeepers of all teams that play in the match. The
+// arena's mutex must be held.
+func (a *Arena) spawnNPCs() {
+	a.npcs = nil
+	for c, t := range a.Teams {
+		cfg := a.Config.Teams[string(c)]
+		if cfg == nil || t.PlayerCount() == 0 {
+			continue
+		}
+		a.addNPC(npc.Shop, c, a.global.NPCs.ShopName, cfg.Shop)
+		a.addNPC(npc.Upgrades, c, a.global.NPCs.UpgradesName, cfg.Upgrades)
+	}
+	npcs, w := a.npcs, a.World
+	a.later(func() {
+		<-w.Exec(func(tx *world.Tx) {
+			for _, n := range npcs {
+				n.Spawn(tx)
+			}
+		})
+	})
+}
+
+// addNPC adds a shopkeeper of team c standing where cfg says. Nothing is
+// added if cfg is nil.
+func (a *Arena) addNPC(kind npc.Kind, c team.Color, name string, cfg *config.NPCConfig) {
+	if cfg == nil {
+		return
+	}
+	s := npc.PlainSkin(c.RGBA())
+	if a.npcSkin != nil {
+		s = *a.npcSkin
+	}
+	a.npcs = append(a.npcs, &npc.NPC{Kind: kind, Team: string(c), Name: name, Pos: cfg.Pos, Yaw: cfg.Yaw, Skin: s})
+}
+
+// closeNPCs removes all shopkeepers of the arena.
+func (a *Arena) closeNPCs() {
+	npcs, w := a.npcs, a.World
+	a.npcs = nil
+	if len(npcs) == 0 {
+		return
+	}
+	a.later(func() {
+		<-w.Exec(func(tx *world.Tx) {
+			for _, n := range npcs {
+				n.Close(tx)
+			}
+		})
+	})
+}
+
+// npc returns the shopkeeper e, if it is one. The arena's mutex must be held.
+func (a *Arena) npc(e world.Entity) (*npc.NPC, bool) {
+	for _, n := range a.npcs {
+		if n.Is(e) {
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+// InteractNPC opens what the shopkeeper e offers to p. It returns false if e
+// is not a shopkeeper of the arena.
+func (a *Arena) InteractNPC(p *player.Player, e world.Entity) bool {
+	a.mu.RLock()
+	n, ok := a.npc(e)
+	pd := a.Players[p.Name()]
+	a.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	if pd == nil || !pd.IsAlive || pd.Spectator || !a.IsPlaying() {
+		return true
+	}
+
+	switch n.Kind {
+	case npc.Shop:
+		a.OpenShop(p)
+	case npc.Upgrades:
+		if pd.Team == nil || string(pd.Team.Color) != n.Team {
+			p.Message("§c✗ You can only upgrade your own team's generators.")
+			return true
+		}
+		a.openUpgrades(p, n, e)
+	}
+	return true
+}
+
+// openUpgrades sends p a dialogue of the shopkeeper n, which is the entity e,
+// to open the team upgrades or pick one of the generators of n's team to
+// upgrade.
+func (a *Arena) openUpgrades(p *player.Player, n *npc.NPC, e world.Entity) {
+	a.mu.RLock()
+	var gens []*generator.Generator
+	for _, g := range a.Generators {
+		// One button is taken by the team upgrades.
+		if g.Team == n.Team && len(gens) < maxDialogueButtons-1 {
+			gens = append(gens, g)
+		}
+	}
+	a.mu.RUnlock()
+
+	count := make(map[generator.ResourceType]int)
+	for _, g := range gens {
+		count[g.ResourceType]++
+	}
+	seen := make(map[generator.ResourceType]int)
+	d := upgradeDialogue{a: a, gens: gens, TeamUpgrades: dialogue.Button{Text: "§bTeam Upgrades"}}
+	for _, g := range gens {
+		text := fmt.Sprintf("%s Generator", g.ResourceType.Title())
+		if count[g.ResourceType] > 1 {
+			seen[g.ResourceType]++
+			text += fmt.Sprintf(" #%d", seen[g.ResourceType])
+		}
+		text += fmt.Sprintf(" §8(Lvl %d)", g.Level())
+		d.buttons = append(d.buttons, dialogue.Button{Text: text})
+	}
+	p.SendDialogue(dialogue.New(d, n.Name).
+		WithBody("§7Buy upgrades for your whole team, or pick a generator of your team to upgrade.").
+		WithButtons(d.buttons...), e)
+}
+
+// upgradeDialogue is the dialogue.Submittable of upgrade shopkeepers. buttons
+// holds one button for every generator in gens.
+type upgradeDialogue struct {
+	TeamUpgrades dialogue.Button
+
+	a       *Arena
+	gens    []*generator.Generator
+	buttons []dialogue.Button
+}
+
+func (d upgradeDialogue) Submit(submitter dialogue.Submitter, pressed dialogue.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok {
+		return
+	}
+	if pressed == d.TeamUpgrades {
+		p.CloseDialogue()
+		d.a.OpenTeamUpgrades(p)
+		return
+	}
+	for i, b := range d.buttons {
+		if b == pressed {
+			p.CloseDialogue()
+			d.a.OpenGeneratorUpgrade(p, d.gens[i])
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package arena
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/match"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// Disconnect keeps p in the running match after it disconnected, so that it
+// may come back through Rejoin within the time configured. It returns false if
+// p is not kept, in which case it must be removed through RemovePlayer.
+// Disconnect must be called with p's transaction.
+func (a *Arena) Disconnect(p *player.Player) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pd, ok := a.Players[p.Name()]
+	grace := a.Config.Phases.Rejoin
+	if !ok || grace < 0 || pd.Team == nil || pd.Spectator || !a.State.InGame() {
+		return false
+	}
+	pd.rejoinBy = a.now.Add(seconds(grace))
+	pd.items, pd.armour = p.Inventory().Slots(), p.Armour().Slots()
+	pd.hits = nil
+	a.broadcast(fmt.Sprintf("%s<yellow> disconnected and has %d seconds to rejoin.</yellow>", a.coloredName(p.Name()), grace))
+
+	// Disconnected players no longer keep a team without an egg in the
+	// match.
+	a.checkWinCondition(a.now)
+	return true
+}
+
+// Rejoinable checks if p disconnected from the running match and may still
+// rejoin it. id is the ID of p's stats, which must be the one p played with.
+func (a *Arena) Rejoinable(p *player.Player, id string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	_, ok := a.away(p.Name(), id)
+	return ok
+}
+
+// away returns the disconnected player with the name passed if it may still
+// rejoin and played with the stats ID passed. The arena's mutex must be held.
+func (a *Arena) away(name, id string) (*PlayerData, bool) {
+	pd, ok := a.Players[name]
+	if !ok || pd.rejoinBy.IsZero() || pd.ID != id || !a.State.InGame() {
+		return nil, false
+	}
+	return pd, true
+}
+
+// Rejoin brings p back into the match it disconnected from, giving back what
+// it carried. It returns the data p played with, which now holds p, or false
+// if p may not rejoin. id is the ID of p's stats.
+func (a *Arena) Rejoin(p *player.Player, id string) (*PlayerData, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pd, ok := a.away(p.Name(), id)
+	if !ok {
+		return nil, false
+	}
+	pd.Player, pd.rejoinBy, pd.scoreboard = p, time.Time{}, nil
+	items, armour := pd.items, pd.armour
+	pd.items, pd.armour = nil, nil
+
+	t, w := pd.Team, a.World
+	// Players that disconnected while waiting to respawn keep waiting for
+	// their delay to pass.
+	waiting, spawn := !pd.respawnAt.IsZero(), t.Spawn
+	if waiting {
+		pd.dead, spawn = false, a.spectatorSpawn()
+	}
+	a.broadcast(fmt.Sprintf("%s<green> rejoined the game!</green>", a.coloredName(p.Name())))
+	a.exec(pd, func(p *player.Player) {
+		inv := p.Inventory()
+		inv.Clear()
+		for slot, s := range items {
+			if !s.Empty() {
+				_ = inv.SetItem(slot, s)
+			}
+		}
+		if len(armour) == 4 {
+			p.Armour().Set(armour[0], armour[1], armour[2], armour[3])
+		}
+		p.SetNameTag(nameTag(t, p.Name()))
+		if waiting {
+			p.SetGameMode(world.GameModeSpectator)
+		} else {
+			p.SetGameMode(world.GameModeSurvival)
+		}
+		p.MoveToWorld(w, spawn)
+		p.Message(fmt.Sprintf("§a✓ You are back in arena '%s'!", a.Name))
+	})
+	return pd, true
+}
+
+// tickAway eliminates the disconnected players that did not rejoin in time.
+// The arena's mutex must be held.
+func (a *Arena) tickAway(now time.Time) {
+	var eliminated bool
+	for _, pd := range a.Players {
+		if !pd.rejoinBy.IsZero() && !now.Before(pd.rejoinBy) {
+			a.eliminateAwayPlayer(pd, "did not rejoin in time")
+			eliminated = true
+		}
+	}
+	if eliminated {
+		a.checkWinCondition(now)
+	}
+}
+
+// eliminateAway eliminates the disconnected players of t once its egg is gone
+// and none of its players are connected, as disconnected players only keep
+// their team in the match while its egg is alive. The arena's mutex must be
+// held.
+func (a *Arena) eliminateAway(t *team.Team) {
+	if t.EggAlive {
+		return
+	}
+	var away []*PlayerData
+	for _, name := range t.Players {
+		pd, ok := a.Players[name]
+		if !ok {
+			continue
+		}
+		if pd.rejoinBy.IsZero() {
+			return
+		}
+		away = append(away, pd)
+	}
+	for _, pd := range away {
+		a.eliminateAwayPlayer(pd, "is disconnected")
+	}
+}
+
+// eliminateAwayPlayer removes the disconnected player pd from the match,
+// telling everyone why it was eliminated. The arena's mutex must be held.
+func (a *Arena) eliminateAwayPlayer(pd *PlayerData, why string) {
+	name := pd.Player.Name()
+	a.broadcast(fmt.Sprintf("%s<white> %s and was eliminated!</white>", a.coloredName(name), why))
+	a.logEvent(match.Event{Type: match.Elimination, Player: name, Team: string(pd.Team.Color)})
+	pd.playedUntil = a.now
+	a.removePlayer(pd)
+}
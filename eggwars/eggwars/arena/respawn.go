@@ -0,0 +1,91 @@
+package arena
+
+import (
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/shop"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/title"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// HandleRespawn picks where p respawns after dying in the arena's match.
+// Players whose respawn delay is over go straight back to their team's spawn.
+// Others wait at the spectator spawn in spectator mode until the delay is
+// over, and eliminated players stay there as spectators. It returns false if
+// p is not in the arena's world.
+func (a *Arena) HandleRespawn(p *player.Player, pos *mgl64.Vec3, w **world.World) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pd, ok := a.Players[p.Name()]
+	if !ok || a.World == nil {
+		return false
+	}
+	pd.dead = false
+	*w = a.World
+
+	switch {
+	case pd.Spectator || pd.Team == nil || !a.State.InGame():
+		*pos = a.spectatorSpawn()
+		a.exec(pd, func(p *player.Player) {
+			giveSpectatorKit(p)
+			p.Message("<gray>You are now spectating. Use the compass to teleport to players.</gray>")
+		})
+	case !a.now.Before(pd.respawnAt):
+		*pos = pd.Team.Spawn
+		pd.respawnAt = time.Time{}
+		a.respawn(pd)
+	default:
+		*pos = a.spectatorSpawn()
+		a.exec(pd, func(p *player.Player) {
+			// The game mode is set once the player is back in the world, so
+			// that it is hidden from the other players.
+			p.SetGameMode(world.GameModeSpectator)
+		})
+	}
+	return true
+}
+
+// respawn brings a player whose respawn delay is over back to its team's
+// spawn with its kit and a fresh set of team armour. The arena's mutex must
+// be held.
+func (a *Arena) respawn(pd *PlayerData) {
+	pd.IsAlive = true
+	spawn, c := pd.Team.Spawn, pd.Team.Color
+	sharpness, protection := pd.Team.Tier(team.Sharpness), pd.Team.Tier(team.Protection)
+	k, hasKit := a.kit(pd)
+	a.exec(pd, func(p *player.Player) {
+		p.SetGameMode(world.GameModeSurvival)
+		if hasKit {
+			k.Apply(p)
+		}
+		giveTeamArmour(p, c)
+		enchantGear(p, sharpness, protection)
+		p.Teleport(spawn)
+		p.SendTitle(title.New("§a§lRESPAWNED!"))
+	})
+}
+
+// clearDrops removes everything p carries except permanent upgrades, so that
+// nothing is dropped when it dies.
+func clearDrops(p *player.Player) {
+	inv := p.Inventory()
+	for slot, s := range inv.Slots() {
+		if !s.Empty() && !shop.IsPermanent(s) {
+			_ = inv.SetItem(slot, item.Stack{})
+		}
+	}
+	a := p.Armour()
+	keep := func(s item.Stack) item.Stack {
+		if shop.IsPermanent(s) {
+			return s
+		}
+		return item.Stack{}
+	}
+	a.Set(keep(a.Helmet()), keep(a.Chestplate()), keep(a.Leggings()), keep(a.Boots()))
+}
@@ -0,0 +1,124 @@
+package arena
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/scoreboard"
+)
+
+// scoreboardInterval is the minimum time between two updates of the
+// scoreboards of an arena.
+const scoreboardInterval = time.Second
+
+// maxScoreboardLines is the maximum amount of lines a scoreboard can show.
+const maxScoreboardLines = 15
+
+// updateScoreboards sends the scoreboard to every player in the arena whose
+// scoreboard changed since it was last sent. It does nothing if the
+// scoreboards were updated less than scoreboardInterval ago.
+func (a *Arena) updateScoreboards(now time.Time) {
+	if now.Sub(a.scoreboardAt) < scoreboardInterval {
+		return
+	}
+	a.scoreboardAt = now
+
+	header := a.scoreboardHeader(now)
+	for _, pd := range a.Players {
+		lines := append(slices.Clone(header), a.scoreboardFooter(pd)...)
+		if len(lines) > maxScoreboardLines {
+			lines = lines[:maxScoreboardLines]
+		}
+		if slices.Equal(lines, pd.scoreboard) {
+			continue
+		}
+		pd.scoreboard = lines
+
+		sb := scoreboard.New("§e§lEGGWARS")
+		sb.RemovePadding()
+		for i, line := range lines {
+			sb.Set(i, line)
+		}
+		a.exec(pd, func(p *player.Player) {
+			p.SendScoreboard(sb)
+		})
+	}
+}
+
+// scoreboardHeader returns the lines of the scoreboard that are the same for
+// every player: the map, the phase timer and the status of every team.
+func (a *Arena) scoreboardHeader(now time.Time) []string {
+	lines := []string{
+		fmt.Sprintf("§7Map: §f%s", a.Config.World),
+		"",
+	}
+	left := a.phaseEnd.Sub(now)
+	switch a.State {
+	case Waiting:
+		lines = append(lines, fmt.Sprintf("§fWaiting... §7(%d/%d)", a.playerCount(), a.Config.MinPlayers))
+	case Starting:
+		lines = append(lines, fmt.Sprintf("§fStarting in §a%s", clock(left)))
+	case Playing:
+		lines = append(lines, fmt.Sprintf("§fSudden death in §e%s", clock(left)))
+	case SuddenDeath:
+		lines = append(lines, fmt.Sprintf("§fGame ends in §c%s", clock(left)))
+	default:
+		lines = append(lines, "§fGame over!")
+	}
+	lines = append(lines, "")
+
+	for _, t := range a.sortedTeams() {
+		egg := "§a✔"
+		if !t.EggAlive {
+			egg = "§c✘"
+		}
+		alive := 0
+		for _, name := range t.Players {
+			if pd, ok := a.Players[name]; ok && pd.IsAlive {
+				alive++
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s■ %s %s §f%d", t.Color.Code(), t.Name, egg, alive))
+	}
+	return lines
+}
+
+// scoreboardFooter returns the lines of the scoreboard that are specific to
+// pd: its kills and the next upgrade of its team's generators.
+func (a *Arena) scoreboardFooter(pd *PlayerData) []string {
+	if !a.State.InGame() && a.State != Ending {
+		return nil
+	}
+	lines := []string{"", fmt.Sprintf("§fKills: §a%d", pd.Kills)}
+	if pd.Team == nil || !pd.IsAlive {
+		return lines
+	}
+	for _, g := range a.Generators {
+		if g.Team != string(pd.Team.Color) {
+			continue
+		}
+		if next, ok := g.NextLevel(); ok {
+			lines = append(lines, fmt.Sprintf("§fNext: %s %s §7(%d %s)", g.ResourceType.Title(), roman(g.Level()+1), next.Cost, next.Currency))
+			break
+		}
+	}
+	return lines
+}
+
+// clock formats d as minutes and seconds.
+func clock(d time.Duration) string {
+	secs := int(max(d, 0).Round(time.Second) / time.Second)
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+// roman returns the level passed as a roman numeral, as shown on generator
+// and team upgrades.
+func roman(level int) string {
+	numerals := [...]string{"I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X"}
+	if level < 1 || level > len(numerals) {
+		return fmt.Sprint(level)
+	}
+	return numerals[level-1]
+}
@@ -0,0 +1,179 @@
+package arena
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/match"
+
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/form"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// Spectate adds p to the running match of the arena as a spectator.
+// Spectators receive the arena's messages, but do not count as players and
+// cannot affect the outcome of the match. It returns false if no match is
+// running.
+func (a *Arena) Spectate(p *player.Player, pd *PlayerData) bool {
+	a.mu.Lock()
+	if !a.State.InGame() {
+		a.mu.Unlock()
+		return false
+	}
+	pd.Arena, pd.Team, pd.IsAlive, pd.Spectator = a, nil, false, true
+	pd.scoreboard = nil
+	pd.Kills, pd.Assists, pd.Deaths, pd.EggsDestroyed, pd.BlocksPlaced = 0, 0, 0, 0, 0
+	a.Players[p.Name()] = pd
+	w, pos := a.World, a.spectatorSpawn()
+	a.mu.Unlock()
+
+	giveSpectatorKit(p)
+	p.MoveToWorld(w, pos)
+	p.Message(fmt.Sprintf("<green>✓ You are now spectating arena '%s'.</green>", a.Name))
+	return true
+}
+
+// eliminate removes a player from the match. The player becomes a spectator
+// once it respawns.
+func (a *Arena) eliminate(pd *PlayerData) {
+	pd.IsAlive, pd.Spectator = false, true
+	pd.playedUntil = a.now
+	if pd.Team != nil {
+		a.logEvent(match.Event{Type: match.Elimination, Player: pd.Player.Name(), Team: string(pd.Team.Color)})
+		// Teams are out of the match once their egg is gone and none of
+		// their players are left.
+		pd.Team.RemovePlayer(pd.Player.Name())
+	}
+}
+
+// SendToLobby clears everything p was given in the arena and brings it back
+// to the lobby. It must be called with p's transaction.
+func (a *Arena) SendToLobby(p *player.Player) {
+	p.Inventory().Clear()
+	p.Armour().Clear()
+	for _, e := range p.Effects() {
+		p.RemoveEffect(e.Type())
+	}
+	p.SetGameMode(world.GameModeSurvival)
+	p.RemoveScoreboard()
+	p.SetNameTag(p.Name())
+	if p.Tx().World() != a.Lobby {
+		p.MoveToWorld(a.Lobby, a.Config.LobbySpawn)
+		return
+	}
+	p.Teleport(a.Config.LobbySpawn)
+}
+
+// spectatorSpawn returns the position spectators are sent to. If the arena
+// has no spectator spawn configured, the spawn of one of its teams is used.
+func (a *Arena) spectatorSpawn() mgl64.Vec3 {
+	if a.Config.SpectatorSpawn != (mgl64.Vec3{}) {
+		return a.Config.SpectatorSpawn
+	}
+	for _, t := range a.Teams {
+		return t.Spawn
+	}
+	return a.Config.LobbySpawn
+}
+
+// playerCount returns the amount of players in the arena, not counting those
+// that only joined to spectate.
+func (a *Arena) playerCount() int {
+	n := 0
+	for _, pd := range a.Players {
+		if !pd.Spectator || pd.Team != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// giveSpectatorKit puts p in spectator mode and gives it the items to
+// teleport to players and to leave the arena.
+func giveSpectatorKit(p *player.Player) {
+	p.Inventory().Clear()
+	p.Armour().Clear()
+	p.SetGameMode(world.GameModeSpectator)
+	_ = p.Inventory().SetItem(0, item.NewStack(item.Compass{}, 1).WithCustomName("§aTeleporter §7(Right-click)"))
+	_ = p.Inventory().SetItem(8, item.NewStack(leaveBed{}, 1).WithCustomName("§cLeave §7(Right-click)"))
+	// The teleporter is a compass too, so it should no longer point at the
+	// enemy last tracked.
+	p.SetCompassTarget(p.Tx().World().Spawn())
+}
+
+// leaveBed is the red bed spectators use to leave the arena. Dragonfly does not
+// implement beds, so it is an item that can only be held and used.
+type leaveBed struct{}
+
+func (leaveBed) EncodeItem() (name string, meta int16) { return "minecraft:bed", 14 }
+func (leaveBed) MaxCount() int                         { return 1 }
+
+func init() {
+	world.RegisterItem(leaveBed{})
+}
+
+// IsSpectatorLeaveItem checks if s is the item spectators use to leave the
+// arena.
+func IsSpectatorLeaveItem(s item.Stack) bool {
+	_, ok := s.Item().(leaveBed)
+	return ok
+}
+
+// OpenTeleporter sends p a form listing the players still alive in the
+// match, teleporting p to the one picked.
+func (a *Arena) OpenTeleporter(p *player.Player) {
+	a.mu.RLock()
+	names := make([]string, 0, len(a.Players))
+	for name, pd := range a.Players {
+		if pd.IsAlive && pd.rejoinBy.IsZero() {
+			names = append(names, name)
+		}
+	}
+	a.mu.RUnlock()
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		p.Message("§c✗ There are no players to teleport to.")
+		return
+	}
+	buttons := make([]form.Button, len(names))
+	for i, name := range names {
+		buttons[i] = form.NewButton(name, "")
+	}
+	p.SendForm(form.NewMenu(teleporterMenu{a: a}, "§aTeleporter").
+		WithBody("Select a player to teleport to:").
+		WithButtons(buttons...))
+}
+
+// teleporterMenu is the MenuSubmittable of the spectator teleporter.
+type teleporterMenu struct {
+	a *Arena
+}
+
+func (m teleporterMenu) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok {
+		return
+	}
+	m.a.mu.RLock()
+	target, ok := m.a.Players[pressed.Text]
+	ok = ok && target.IsAlive && target.rejoinBy.IsZero()
+	var h *world.EntityHandle
+	if ok {
+		h = target.Player.H()
+	}
+	m.a.mu.RUnlock()
+	if !ok {
+		p.Message("§c✗ That player is no longer in the game.")
+		return
+	}
+	e, ok := h.Entity(tx)
+	if !ok {
+		p.Message("§c✗ That player is no longer in the game.")
+		return
+	}
+	p.Teleport(e.Position())
+}
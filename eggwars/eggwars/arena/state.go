@@ -0,0 +1,279 @@
+package arena
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/title"
+)
+
+type GameState int
+
+const (
+	Waiting GameState = iota
+	Starting
+	Playing
+	SuddenDeath
+	Ending
+	Resetting
+)
+
+func (s GameState) String() string {
+	switch s {
+	case Waiting:
+		return "Waiting"
+	case Starting:
+		return "Starting"
+	case Playing:
+		return "Playing"
+	case SuddenDeath:
+		return "Sudden Death"
+	case Ending:
+		return "Ending"
+	default:
+		return "Resetting"
+	}
+}
+
+// InGame checks if a match is being played in the state.
+func (s GameState) InGame() bool {
+	return s == Playing || s == SuddenDeath
+}
+
+// phase holds the hooks of a GameState. Each hook is called with the arena's
+// mutex held and must not block.
+type phase struct {
+	enter func(a *Arena, now time.Time)
+	tick  func(a *Arena, now time.Time)
+	exit  func(a *Arena, now time.Time)
+}
+
+// phases holds the hooks of every GameState. It is filled in init, as the
+// hooks themselves refer back to it through setState.
+var phases map[GameState]phase
+
+func init() {
+	phases = map[GameState]phase{
+		Waiting:     {tick: (*Arena).tickWaiting},
+		Starting:    {enter: (*Arena).enterStarting, tick: (*Arena).tickStarting},
+		Playing:     {enter: (*Arena).enterPlaying, tick: (*Arena).tickPlaying},
+		SuddenDeath: {enter: (*Arena).enterSuddenDeath, tick: (*Arena).tickPlaying},
+		Ending:      {enter: (*Arena).enterEnding, tick: (*Arena).tickEnding},
+		Resetting:   {enter: (*Arena).enterResetting},
+	}
+}
+
+// setState moves the arena to another state, calling the exit hook of the
+// current state and the enter hook of the new one.
+func (a *Arena) setState(s GameState, now time.Time) {
+	if p := phases[a.State]; p.exit != nil {
+		p.exit(a, now)
+	}
+	a.State = s
+	a.phaseEnd = time.Time{}
+	if p := phases[s]; p.enter != nil {
+		p.enter(a, now)
+	}
+}
+
+func (a *Arena) tickWaiting(now time.Time) {
+	if len(a.Players) >= a.Config.MinPlayers {
+		a.setState(Starting, now)
+	}
+}
+
+func (a *Arena) enterStarting(now time.Time) {
+	a.phaseEnd = now.Add(seconds(a.Config.Phases.Countdown))
+	a.broadcast(fmt.Sprintf("<green>Game starting in %d seconds!</green>", a.Config.Phases.Countdown))
+	if a.instance == nil && !a.opening {
+		// Copying the template may take a while, so it is done during the
+		// countdown rather than once it ends.
+		a.opening = true
+		a.later(a.openInstance)
+	}
+}
+
+func (a *Arena) tickStarting(now time.Time) {
+	if len(a.Players) < a.Config.MinPlayers {
+		a.broadcast("<red>Not enough players! Countdown cancelled.</red>")
+		a.setState(Waiting, now)
+		return
+	}
+	if a.instanceErr != nil {
+		a.broadcast("<red>The map could not be loaded, the game was cancelled.</red>")
+		a.instanceErr = nil
+		a.setState(Waiting, now)
+		return
+	}
+	left := a.phaseEnd.Sub(now)
+	if left > 0 {
+		if secs := int(left.Round(time.Second) / time.Second); secs != a.lastCount && (secs <= 5 || secs%10 == 0) {
+			a.lastCount = secs
+			a.broadcast(fmt.Sprintf("<yellow>Game starting in %d...</yellow>", secs))
+		}
+		return
+	}
+	if a.instance != nil {
+		a.setState(Playing, now)
+	}
+}
+
+func (a *Arena) enterPlaying(now time.Time) {
+	a.phaseEnd = now.Add(seconds(a.Config.Phases.Duration))
+	a.lastCount = 0
+	a.assignTeams()
+	a.startLog(now)
+	for _, t := range a.Teams {
+		if t.PlayerCount() == 0 {
+			// Teams nobody plays in are out from the start.
+			t.BreakEgg()
+		}
+	}
+	a.initGenerators()
+	a.spawnNPCs()
+	a.placeEggs()
+
+	a.broadcast("<gold>===== GAME STARTED! =====</gold>")
+	a.broadcast("<yellow>Protect your egg and destroy others!</yellow>")
+
+	w := a.World
+	for _, pd := range a.Players {
+		pd.IsAlive = true
+		pd.playedFrom, pd.playedUntil, pd.hits = now, time.Time{}, nil
+		if pd.Team == nil {
+			continue
+		}
+		t := pd.Team
+		k, hasKit := a.kit(pd)
+		a.exec(pd, func(p *player.Player) {
+			// Currency is held in the inventory, so nothing may be carried
+			// over from the lobby.
+			p.Inventory().Clear()
+			p.Armour().Clear()
+			if hasKit {
+				k.Apply(p)
+			}
+			giveTeamArmour(p, t.Color)
+			p.SetNameTag(nameTag(t, p.Name()))
+			p.MoveToWorld(w, t.Spawn)
+			p.Message(fmt.Sprintf("%sYou are in %s!", t.Color.Code(), t.Title()))
+		})
+	}
+}
+
+func (a *Arena) tickPlaying(now time.Time) {
+	for _, pd := range a.Players {
+		// Players still on the respawn screen are sent to their spawn by
+		// HandleRespawn once they respawn, and disconnected players once
+		// they rejoin.
+		if pd.respawnAt.IsZero() || pd.dead || !pd.rejoinBy.IsZero() {
+			continue
+		}
+		if !now.Before(pd.respawnAt) {
+			pd.respawnAt = time.Time{}
+			a.respawn(pd)
+			continue
+		}
+		if secs := int(pd.respawnAt.Sub(now).Round(time.Second) / time.Second); secs != pd.respawnCount {
+			pd.respawnCount = secs
+			a.exec(pd, func(p *player.Player) {
+				p.SendTitle(title.New("§c§lYOU DIED").WithSubtitle(fmt.Sprintf("§eRespawning in %d...", secs)))
+			})
+		}
+	}
+	a.tickGenerators(now)
+	a.tickUpgrades(now)
+	a.tickPlatforms(now)
+	a.tickTrackers()
+	a.tickAway(now)
+	if !a.State.InGame() {
+		return
+	}
+
+	if !now.Before(a.phaseEnd) {
+		if a.State == Playing {
+			a.setState(SuddenDeath, now)
+			return
+		}
+		a.broadcast("<red>Time is up!</red>")
+		a.endGame(nil, now)
+	}
+}
+
+func (a *Arena) enterSuddenDeath(now time.Time) {
+	a.phaseEnd = now.Add(seconds(a.Config.Phases.SuddenDeath))
+	a.broadcast("<dark-red>===== SUDDEN DEATH =====</dark-red>")
+	a.broadcast("<red>All eggs have been destroyed. Last team standing wins!</red>")
+	for _, t := range a.Teams {
+		a.breakEgg(t)
+	}
+	a.checkWinCondition(now)
+}
+
+func (a *Arena) enterEnding(now time.Time) {
+	a.phaseEnd = now.Add(seconds(a.Config.Phases.Ending))
+	a.closeGenerators()
+
+	for _, pd := range a.Players {
+		// Players that joined only to spectate have no team.
+		if pd.Team != nil {
+			a.recordStats(pd, pd.Team == a.winner)
+		}
+	}
+
+	if a.winner != nil {
+		a.broadcast("<gold>===== GAME OVER! =====</gold>")
+		a.broadcast(fmt.Sprintf("%s wins!", a.winner.Title()))
+	} else {
+		a.broadcast("<gold>Game ended with no winners!</gold>")
+	}
+	a.rate()
+	a.endLog()
+}
+
+func (a *Arena) tickEnding(now time.Time) {
+	if !now.Before(a.phaseEnd) {
+		a.setState(Resetting, now)
+	}
+}
+
+func (a *Arena) enterResetting(now time.Time) {
+	players := a.Players
+	a.Players = make(map[string]*PlayerData)
+	for _, pd := range players {
+		pd.Arena, pd.Team, pd.IsAlive, pd.Spectator, pd.respawnAt, pd.dead = nil, nil, false, false, time.Time{}, false
+		a.exec(pd, a.SendToLobby)
+	}
+	a.closeNPCs()
+	w, j := a.World, a.Journal
+	a.later(func() {
+		if a.global.ReuseInstances {
+			a.rollback(w, j)
+		} else {
+			a.discardInstance()
+		}
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.PlacedBlocks = make(map[cube.Pos]bool)
+		a.Teams = make(map[team.Color]*team.Team)
+		a.winner, a.match, a.leavers, a.platforms = nil, nil, nil, nil
+		a.initTeams()
+		a.setState(Waiting, a.now)
+	})
+}
+
+// endGame ends the match with the team passed as the winner. winner may be nil
+// if the match ended without one.
+func (a *Arena) endGame(winner *team.Team, now time.Time) {
+	a.winner = winner
+	a.setState(Ending, now)
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
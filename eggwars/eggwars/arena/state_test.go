@@ -0,0 +1,230 @@
+package arena
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/kit"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/stats"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/utility"
+
+	"github.com/df-mc/dragonfly/server"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/skin"
+	"github.com/df-mc/dragonfly/server/session"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/google/uuid"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/login"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	// Blocks such as eggs can only be placed once the block registry is
+	// final, which happens when the first server is created.
+	server.Config{Log: slog.New(slog.DiscardHandler), DisableResourceBuilding: true}.New()
+	os.Exit(m.Run())
+}
+
+// newTestWorld returns an empty world that is closed when the test ends.
+func newTestWorld(t *testing.T) *world.World {
+	t.Helper()
+	w := world.Config{Entities: utility.Entities(entity.DefaultRegistry)}.New()
+	t.Cleanup(func() { _ = w.Close() })
+	return w
+}
+
+// newTestArena returns an arena with a red and a blue team of one player each,
+// whose clock starts at start. Its match world is already open, so the
+// countdown does not wait on a template map being copied.
+func newTestArena(t *testing.T, start time.Time) *Arena {
+	t.Helper()
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+
+	cfg := &config.ArenaConfig{
+		MinPlayers: 2,
+		TeamSize:   1,
+		MaxPlayers: 2,
+		Teams: map[string]*config.TeamConfig{
+			"red":  {Spawn: mgl64.Vec3{10, 5, 0}, Egg: cube.Pos{12, 5, 0}},
+			"blue": {Spawn: mgl64.Vec3{-10, 5, 0}, Egg: cube.Pos{-12, 5, 0}},
+		},
+		Phases: config.PhaseConfig{Countdown: 10, Duration: 60, SuddenDeath: 30, Ending: 5, Respawn: 5, Rejoin: 30},
+	}
+	global := &config.Config{MatchesDir: t.TempDir()}
+	st := stats.NewStatsManager(stats.NewMemoryStore(), config.StatsConfig{FlushInterval: 60, InitialRating: 1000, RatingK: 32}, log)
+	t.Cleanup(func() { _ = st.Close() })
+
+	a := newArena("test", cfg, global, log, newTestWorld(t), nil, kit.New(nil, log), st, start)
+	w := newTestWorld(t)
+	a.instance, a.World = &instance{w: w}, w
+	return a
+}
+
+// addTestPlayer adds a player with the name passed to the lobby of a and joins
+// it to the arena.
+func addTestPlayer(t *testing.T, a *Arena, name string) *player.Player {
+	t.Helper()
+	id := uuid.New()
+	conn := &testConn{id: login.IdentityData{DisplayName: name, Identity: id.String()}, closed: make(chan struct{})}
+	s := session.Config{Log: slog.New(slog.DiscardHandler)}.New(conn)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	var p *player.Player
+	<-a.Lobby.Exec(func(tx *world.Tx) {
+		h := world.EntitySpawnOpts{Position: mgl64.Vec3{0, 5, 0}}.New(player.Type, player.Config{Name: name, UUID: id, Session: s})
+		s.SetHandle(h, skin.Skin{})
+		p = tx.AddEntity(h).(*player.Player)
+	})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Players[name] = &PlayerData{Player: p, ID: name, Arena: a, IsAlive: true, joinedAt: a.now}
+	return p
+}
+
+// testConn is a session.Conn that discards everything sent to it, so that
+// players in tests can be sent messages and forms.
+type testConn struct {
+	id     login.IdentityData
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (c *testConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+func (c *testConn) IdentityData() login.IdentityData { return c.id }
+func (c *testConn) ClientData() login.ClientData     { return login.ClientData{} }
+func (c *testConn) ClientCacheEnabled() bool         { return false }
+func (c *testConn) ChunkRadius() int                 { return 4 }
+func (c *testConn) Latency() time.Duration           { return 0 }
+func (c *testConn) Flush() error                     { return nil }
+func (c *testConn) RemoteAddr() net.Addr             { return &net.UDPAddr{} }
+func (c *testConn) WritePacket(packet.Packet) error  { return nil }
+func (c *testConn) StartGameContext(context.Context, minecraft.GameData) error {
+	return nil
+}
+func (c *testConn) ReadPacket() (packet.Packet, error) {
+	<-c.closed
+	return nil, net.ErrClosed
+}
+
+// recordStates records every state entered by any arena until the test ends.
+func recordStates(t *testing.T) *[]GameState {
+	t.Helper()
+	var states []GameState
+	orig := phases
+	phases = make(map[GameState]phase, len(orig))
+	for s, p := range orig {
+		enter := p.enter
+		p.enter = func(a *Arena, now time.Time) {
+			states = append(states, s)
+			if enter != nil {
+				enter(a, now)
+			}
+		}
+		phases[s] = p
+	}
+	t.Cleanup(func() { phases = orig })
+	return &states
+}
+
+func (a *Arena) state() GameState {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.State
+}
+
+func TestArenaLifecycle(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	states := recordStates(t)
+	a := newTestArena(t, start)
+	addTestPlayer(t, a, "Alice")
+	addTestPlayer(t, a, "Bob")
+
+	steps := []struct {
+		name string
+		at   time.Duration
+		want GameState
+	}{
+		{"enough players start the countdown", 0, Starting},
+		{"countdown running", 9 * time.Second, Starting},
+		{"countdown over", 10 * time.Second, Playing},
+		{"match running", 69 * time.Second, Playing},
+		{"duration over", 70 * time.Second, SuddenDeath},
+		{"sudden death running", 99 * time.Second, SuddenDeath},
+		{"sudden death over", 100 * time.Second, Ending},
+		{"ending running", 104 * time.Second, Ending},
+		// The arena resets within the tick that enters Resetting, after
+		// which it waits for players again.
+		{"ending over", 105 * time.Second, Waiting},
+	}
+	for _, step := range steps {
+		a.Tick(start.Add(step.at))
+		if got := a.state(); got != step.want {
+			t.Fatalf("%s: state after %v is %v, want %v", step.name, step.at, got, step.want)
+		}
+	}
+
+	want := []GameState{Starting, Playing, SuddenDeath, Ending, Resetting, Waiting}
+	if len(*states) != len(want) {
+		t.Fatalf("states entered: got %v, want %v", *states, want)
+	}
+	for i, s := range want {
+		if (*states)[i] != s {
+			t.Fatalf("states entered: got %v, want %v", *states, want)
+		}
+	}
+	if n := len(a.Players); n != 0 {
+		t.Errorf("players left after reset: got %d, want 0", n)
+	}
+	if a.instance != nil || a.World != nil {
+		t.Errorf("instance of the match kept after reset")
+	}
+}
+
+func TestArenaEndsEarly(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		// leave is the time after start at which Bob leaves.
+		leave time.Duration
+		want  GameState
+	}{
+		{"leaving during the countdown cancels it", 5 * time.Second, Waiting},
+		{"a team keeps playing while its egg stands", 20 * time.Second, Playing},
+		{"the last team standing in sudden death wins", 80 * time.Second, Ending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestArena(t, start)
+			addTestPlayer(t, a, "Alice")
+			bob := addTestPlayer(t, a, "Bob")
+
+			for at := time.Duration(0); at < tt.leave; at += time.Second {
+				a.Tick(start.Add(at))
+			}
+			a.RemovePlayer(bob)
+			a.Tick(start.Add(tt.leave))
+			if got := a.state(); got != tt.want {
+				t.Fatalf("state: got %v, want %v", got, tt.want)
+			}
+			if tt.want == Ending && (a.winner == nil || a.winner.PlayerCount() != 1) {
+				t.Errorf("winner: got %v, want the team of Alice", a.winner)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package arena
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/kit"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/form"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// sortedTeams returns the teams of the arena sorted by name, so that ties
+// between teams are always broken the same way.
+func (a *Arena) sortedTeams() []*team.Team {
+	teams := make([]*team.Team, 0, len(a.Teams))
+	for _, t := range a.Teams {
+		teams = append(teams, t)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].Name < teams[j].Name
+	})
+	return teams
+}
+
+// teamCapacity returns the maximum amount of players in a single team.
+func (a *Arena) teamCapacity() int {
+	return a.Config.TeamSize
+}
+
+// canJoin checks if pd may pick team t. A team may not be picked if it is full,
+// or if it would end up with more than one player over the smallest team.
+// The first return value is false if the team is full, the second if it is
+// locked to keep the teams balanced.
+func (a *Arena) canJoin(pd *PlayerData, t *team.Team) (notFull, unlocked bool) {
+	count := func(t *team.Team) int {
+		n := t.PlayerCount()
+		if pd.Team == t {
+			n--
+		}
+		return n
+	}
+	smallest := -1
+	for _, other := range a.Teams {
+		if n := count(other); smallest == -1 || n < smallest {
+			smallest = n
+		}
+	}
+	n := count(t)
+	return n < a.teamCapacity(), n <= smallest
+}
+
+// SelectTeam moves p to the team with the colour passed, if the team may be
+// picked. Teams can only be picked before the match starts.
+func (a *Arena) SelectTeam(p *player.Player, c team.Color) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pd, ok := a.Players[p.Name()]
+	t, tok := a.Teams[c]
+	if !ok || !tok || (a.State != Waiting && a.State != Starting) {
+		return
+	}
+	if pd.Team == t {
+		p.Message(fmt.Sprintf("§eYou are already in %s§e.", t.Title()))
+		return
+	}
+	if notFull, unlocked := a.canJoin(pd, t); !notFull {
+		p.Message(fmt.Sprintf("§c✗ %s §cis full.", t.Title()))
+		return
+	} else if !unlocked {
+		p.Message(fmt.Sprintf("§c✗ %s §chas too many players, pick another team.", t.Title()))
+		return
+	}
+	if pd.Team != nil {
+		pd.Team.RemovePlayer(p.Name())
+	}
+	pd.Team = t
+	t.AddPlayer(p.Name())
+	p.Message(fmt.Sprintf("§a✓ You joined %s§a!", t.Title()))
+}
+
+// OpenTeamSelector sends p a form listing the teams of the arena with their
+// amount of players.
+func (a *Arena) OpenTeamSelector(p *player.Player) {
+	a.mu.RLock()
+	pd, ok := a.Players[p.Name()]
+	if !ok || (a.State != Waiting && a.State != Starting) {
+		a.mu.RUnlock()
+		return
+	}
+	teams := a.sortedTeams()
+	buttons := make([]form.Button, len(teams))
+	for i, t := range teams {
+		status := fmt.Sprintf("§7%d/%d players", t.PlayerCount(), a.teamCapacity())
+		if notFull, unlocked := a.canJoin(pd, t); pd.Team == t {
+			status = "§aSelected"
+		} else if !notFull {
+			status = "§8FULL"
+		} else if !unlocked {
+			status = "§8LOCKED"
+		}
+		buttons[i] = form.NewButton(fmt.Sprintf("%s\n%s", t.Title(), status), "")
+	}
+	a.mu.RUnlock()
+
+	p.SendForm(form.NewMenu(teamMenu{a: a, teams: teams, buttons: buttons}, "§6Select a team").
+		WithBody("Teams may have at most one player more than the smallest team.").
+		WithButtons(buttons...))
+}
+
+// teamMenu is the MenuSubmittable of the team selector.
+type teamMenu struct {
+	a       *Arena
+	teams   []*team.Team
+	buttons []form.Button
+}
+
+func (m teamMenu) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok {
+		return
+	}
+	for i, b := range m.buttons {
+		if b == pressed {
+			m.a.SelectTeam(p, m.teams[i].Color)
+			return
+		}
+	}
+}
+
+// assignTeams puts all players that did not pick a team in one. Players of the
+// same group are kept together where possible: they join the team a member
+// of the group already picked, or are otherwise assigned to a team together.
+func (a *Arena) assignTeams() {
+	teams := a.sortedTeams()
+	capacity := a.teamCapacity()
+
+	groups := make(map[string][]*PlayerData)
+	var keys []string
+	for name, pd := range a.Players {
+		if pd.Spectator {
+			continue
+		}
+		key := pd.Group
+		if key == "" {
+			key = "\x00" + name
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], pd)
+	}
+	// Larger groups are assigned first, as they are the hardest to fit.
+	sort.Slice(keys, func(i, j int) bool {
+		if len(groups[keys[i]]) != len(groups[keys[j]]) {
+			return len(groups[keys[i]]) > len(groups[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+
+	join := func(pd *PlayerData, t *team.Team) {
+		pd.Team = t
+		t.AddPlayer(pd.Player.Name())
+	}
+	smallest := func(need int) *team.Team {
+		var best *team.Team
+		for _, t := range teams {
+			if t.PlayerCount()+need <= capacity && (best == nil || t.PlayerCount() < best.PlayerCount()) {
+				best = t
+			}
+		}
+		return best
+	}
+
+	for _, key := range keys {
+		var chosen *team.Team
+		var rest []*PlayerData
+		for _, pd := range groups[key] {
+			if pd.Team != nil {
+				chosen = pd.Team
+			} else {
+				rest = append(rest, pd)
+			}
+		}
+		if len(rest) == 0 {
+			continue
+		}
+		if chosen == nil || chosen.PlayerCount()+len(rest) > capacity {
+			chosen = smallest(min(len(rest), capacity))
+		}
+		for _, pd := range rest {
+			if chosen == nil || chosen.PlayerCount() >= capacity {
+				// The group does not fit in a single team, so it is split
+				// into as few teams as possible.
+				if chosen = smallest(min(len(rest), capacity)); chosen == nil {
+					chosen = smallest(1)
+				}
+			}
+			if chosen != nil {
+				join(pd, chosen)
+			}
+			rest = rest[1:]
+		}
+	}
+}
+
+// Room returns the amount of players that may still join the arena, and the
+// most of them that fit in a single team together. Both are 0 if the arena
+// cannot be joined right now.
+func (a *Arena) Room() (players, team int) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.State != Waiting && a.State != Starting {
+		return 0, 0
+	}
+	for _, t := range a.Teams {
+		team = max(team, a.teamCapacity()-t.PlayerCount())
+	}
+	return max(a.Config.MaxPlayers-len(a.Players), 0), team
+}
+
+// teamSelectorKey and kitSelectorKey are the keys of the values that mark the
+// items used to open the team and kit selectors.
+const (
+	teamSelectorKey = "eggwars:team_selector"
+	kitSelectorKey  = "eggwars:kit_selector"
+)
+
+// giveLobbyKit gives p the items used while waiting for a match to start.
+func (a *Arena) giveLobbyKit(p *player.Player) {
+	p.Inventory().Clear()
+	p.Armour().Clear()
+	_ = p.Inventory().SetItem(0, item.NewStack(item.NetherStar{}, 1).WithCustomName("§eTeam Selector §7(Right-click)").WithValue(teamSelectorKey, true))
+	if len(a.kits.All()) > 0 {
+		_ = p.Inventory().SetItem(1, item.NewStack(item.Book{}, 1).WithCustomName("§bKit Selector §7(Right-click)").WithValue(kitSelectorKey, true))
+	}
+}
+
+// IsKitSelectorItem checks if s is the item used to open the kit selector.
+func IsKitSelectorItem(s item.Stack) bool {
+	_, ok := s.Value(kitSelectorKey)
+	return ok
+}
+
+// OpenKitSelector sends p a form to pick the kit it spawns with, if it is
+// waiting for the arena's match to start.
+func (a *Arena) OpenKitSelector(p *player.Player) {
+	a.mu.RLock()
+	pd, ok := a.Players[p.Name()]
+	waiting := a.State == Waiting || a.State == Starting
+	a.mu.RUnlock()
+	if !ok || !waiting {
+		return
+	}
+	a.kits.OpenSelector(p, a.stats, pd.ID)
+}
+
+// kit returns the kit that pd spawns with. It returns false if pd has no kit.
+func (a *Arena) kit(pd *PlayerData) (*kit.Kit, bool) {
+	st := a.stats.GetStats(pd.ID, pd.Player.Name())
+	return a.kits.Selected(st.Kit, st.Kits)
+}
+
+// IsTeamSelectorItem checks if s is the item used to open the team selector.
+func IsTeamSelectorItem(s item.Stack) bool {
+	_, ok := s.Value(teamSelectorKey)
+	return ok
+}
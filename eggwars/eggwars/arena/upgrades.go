@@ -0,0 +1,373 @@
+package arena
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/match"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/shop"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/entity/effect"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/item/enchantment"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/form"
+	"github.com/df-mc/dragonfly/server/player/title"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/sound"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// islandEffect is the duration of the haste and regeneration players get on
+// their island. The effects are renewed every second, so they wear off soon
+// after a player leaves its island.
+const islandEffect = 4 * time.Second
+
+// OpenTeamUpgrades sends p a form listing the upgrades of its team and the
+// traps set on its island.
+func (a *Arena) OpenTeamUpgrades(p *player.Player) {
+	a.mu.RLock()
+	pd, ok := a.Players[p.Name()]
+	if !ok || pd.Team == nil || !pd.IsAlive || !a.State.InGame() {
+		a.mu.RUnlock()
+		return
+	}
+	t, cfg := pd.Team, a.global.Upgrades
+	m := upgradeMenu{a: a}
+	for _, u := range team.Upgrades {
+		costs := cfg.Costs(string(u))
+		if len(costs) == 0 {
+			continue
+		}
+		status := "§aMAXED"
+		if tier := t.Tier(u); tier < len(costs) {
+			status = fmt.Sprintf("§7Tier %s: §f%d %s", roman(tier+1), costs[tier], cfg.Currency)
+		}
+		m.upgrades = append(m.upgrades, u)
+		m.buttons = append(m.buttons, form.NewButton(fmt.Sprintf("§b%s\n%s", u.Title(), status), ""))
+	}
+	if len(cfg.Traps) > 0 {
+		m.buttons = append(m.buttons, form.NewButton(fmt.Sprintf("§cTraps\n§7%d/%d set", len(t.Traps()), min(team.MaxTraps, len(cfg.Traps))), ""))
+	}
+	a.mu.RUnlock()
+
+	if len(m.buttons) == 0 {
+		p.Message("§c✗ There are no team upgrades for sale.")
+		return
+	}
+	p.SendForm(form.NewMenu(m, "§bTeam Upgrades").
+		WithBody("Upgrades last for the rest of the match and help all players of your team.").
+		WithButtons(m.buttons...))
+}
+
+// upgradeMenu is the MenuSubmittable of the team upgrades. It holds a button
+// for every upgrade in upgrades, followed by the button of the traps.
+type upgradeMenu struct {
+	a        *Arena
+	upgrades []team.Upgrade
+	buttons  []form.Button
+}
+
+func (m upgradeMenu) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok {
+		return
+	}
+	for i, b := range m.buttons {
+		if b != pressed {
+			continue
+		}
+		if i < len(m.upgrades) {
+			m.a.buyUpgrade(p, m.upgrades[i])
+		} else {
+			m.a.openTraps(p)
+		}
+		return
+	}
+}
+
+// openTraps sends p a form to set a trap on the island of its team.
+func (a *Arena) openTraps(p *player.Player) {
+	a.mu.RLock()
+	pd, ok := a.Players[p.Name()]
+	if !ok || pd.Team == nil || !pd.IsAlive || !a.State.InGame() {
+		a.mu.RUnlock()
+		return
+	}
+	cfg, set := a.global.Upgrades, pd.Team.Traps()
+	a.mu.RUnlock()
+
+	body := "§7Traps trigger once when an enemy enters your island, in the order they were set."
+	for i, trap := range set {
+		body += fmt.Sprintf("\n§f%d. %s", i+1, trap.Title())
+	}
+	m := trapMenu{a: a}
+	for _, trap := range team.Traps {
+		status := "§cFULL"
+		if n := len(set); n < team.MaxTraps && n < len(cfg.Traps) {
+			status = fmt.Sprintf("§7%d %s", cfg.Traps[n], cfg.Currency)
+		}
+		m.traps = append(m.traps, trap)
+		m.buttons = append(m.buttons, form.NewButton(fmt.Sprintf("§c%s\n%s", trap.Title(), status), ""))
+	}
+	p.SendForm(form.NewMenu(m, "§cTraps").WithBody(body).WithButtons(m.buttons...))
+}
+
+// trapMenu is the MenuSubmittable of the traps. It holds a button for every
+// trap in traps.
+type trapMenu struct {
+	a       *Arena
+	traps   []team.Trap
+	buttons []form.Button
+}
+
+func (m trapMenu) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok {
+		return
+	}
+	for i, b := range m.buttons {
+		if b == pressed {
+			m.a.buyTrap(p, m.traps[i])
+			return
+		}
+	}
+}
+
+// buyUpgrade buys the next tier of the upgrade passed for the team of p and
+// tells the team about it.
+func (a *Arena) buyUpgrade(p *player.Player, u team.Upgrade) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pd, ok := a.Players[p.Name()]
+	if !ok || pd.Team == nil || !pd.IsAlive || !a.State.InGame() {
+		return
+	}
+	t, cfg := pd.Team, a.global.Upgrades
+	costs := cfg.Costs(string(u))
+	tier := t.Tier(u)
+	if tier >= len(costs) {
+		p.Message(fmt.Sprintf("§c✗ %s is already at its highest tier.", u.Title()))
+		return
+	}
+	if !pay(p, cfg.Currency, costs[tier]) {
+		return
+	}
+	tier = t.Raise(u)
+	a.logEvent(match.Event{Type: match.Upgrade, Player: p.Name(), Team: string(t.Color), Item: string(u), Level: tier})
+	a.applyUpgrade(t, u)
+
+	msg := fmt.Sprintf("§a✓ %s §abought §b%s %s§a!", a.coloredName(p.Name()), u.Title(), roman(tier))
+	a.teamExec(t, func(p *player.Player) {
+		p.Message(msg)
+	})
+}
+
+// buyTrap sets a trap on the island of the team of p and tells the team about
+// it.
+func (a *Arena) buyTrap(p *player.Player, trap team.Trap) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pd, ok := a.Players[p.Name()]
+	if !ok || pd.Team == nil || !pd.IsAlive || !a.State.InGame() {
+		return
+	}
+	t, cfg := pd.Team, a.global.Upgrades
+	n := len(t.Traps())
+	if n >= team.MaxTraps || n >= len(cfg.Traps) {
+		p.Message("§c✗ Your team cannot set any more traps.")
+		return
+	}
+	if !pay(p, cfg.Currency, cfg.Traps[n]) {
+		return
+	}
+	t.SetTrap(trap)
+	a.logEvent(match.Event{Type: match.Upgrade, Player: p.Name(), Team: string(t.Color), Item: string(trap), Level: n + 1})
+
+	msg := fmt.Sprintf("§a✓ %s §aset a §c%s§a!", a.coloredName(p.Name()), trap.Title())
+	a.teamExec(t, func(p *player.Player) {
+		p.Message(msg)
+	})
+}
+
+// pay takes an amount of a currency from the inventory of p. It tells p and
+// returns false if p does not have enough of it.
+func pay(p *player.Player, currency string, amount int) bool {
+	w := shop.InventoryWallet{Inv: p.Inventory()}
+	if have := w.Balance(currency); have < amount || !w.Take(currency, amount) {
+		p.Message(fmt.Sprintf("§c✗ Not enough %s! Need: %d, Have: %d", currency, amount, have))
+		return false
+	}
+	return true
+}
+
+// applyUpgrade applies the upgrade u that t just bought. Haste and
+// regeneration need nothing here, they are given out by tickUpgrades. The
+// arena's mutex must be held.
+func (a *Arena) applyUpgrade(t *team.Team, u team.Upgrade) {
+	switch u {
+	case team.Sharpness, team.Protection:
+		sharpness, protection := t.Tier(team.Sharpness), t.Tier(team.Protection)
+		a.teamExec(t, func(p *player.Player) {
+			enchantGear(p, sharpness, protection)
+		})
+	case team.Forge:
+		boost := float64(t.Tier(team.Forge)) * a.global.Upgrades.ForgeSpeed
+		for _, g := range a.Generators {
+			if g.Team == string(t.Color) {
+				g.Boost(boost)
+			}
+		}
+	}
+}
+
+// enchantGear enchants the swords, axes and armour of p with the sharpness and
+// protection levels passed.
+func enchantGear(p *player.Player, sharpness, protection int) {
+	inv := p.Inventory()
+	for i, s := range inv.Slots() {
+		if e, ok := enchantStack(s, sharpness, protection); ok {
+			_ = inv.SetItem(i, e)
+		}
+	}
+	a := p.Armour()
+	piece := func(s item.Stack) item.Stack {
+		e, _ := enchantStack(s, sharpness, protection)
+		return e
+	}
+	a.Set(piece(a.Helmet()), piece(a.Chestplate()), piece(a.Leggings()), piece(a.Boots()))
+}
+
+// enchantStack enchants s with sharpness if it is a weapon, or with protection
+// if it is armour. It returns false if s was left as it is.
+func enchantStack(s item.Stack, sharpness, protection int) (item.Stack, bool) {
+	if s.Empty() {
+		return s, false
+	}
+	for _, e := range []struct {
+		t   item.EnchantmentType
+		lvl int
+	}{{enchantment.Sharpness, sharpness}, {enchantment.Protection, protection}} {
+		if e.lvl <= 0 || !e.t.CompatibleWithItem(s.Item()) {
+			continue
+		}
+		if cur, ok := s.Enchantment(e.t); ok && cur.Level() >= e.lvl {
+			continue
+		}
+		return s.WithEnchantments(item.NewEnchantment(e.t, e.lvl)), true
+	}
+	return s, false
+}
+
+// tickUpgrades gives haste and regeneration to the players on the islands of
+// teams that bought them. The arena's mutex must be held.
+func (a *Arena) tickUpgrades(now time.Time) {
+	if now.Sub(a.upgradesAt) < time.Second {
+		return
+	}
+	a.upgradesAt = now
+	w, radius := a.World, float64(a.global.Upgrades.Radius)
+	for _, pd := range a.Players {
+		if !pd.IsAlive || pd.Team == nil || !pd.respawnAt.IsZero() {
+			continue
+		}
+		haste, regen, spawn := pd.Team.Tier(team.Haste), pd.Team.Tier(team.Regeneration), pd.Team.Spawn
+		if haste == 0 && regen == 0 {
+			continue
+		}
+		a.exec(pd, func(p *player.Player) {
+			if p.Tx().World() != w || !onIsland(p.Position(), spawn, radius) {
+				return
+			}
+			renewEffect(p, effect.Haste, haste)
+			renewEffect(p, effect.Regeneration, regen)
+		})
+	}
+}
+
+// renewEffect gives p the effect passed at level lvl for islandEffect, unless
+// p has it at a higher level or for longer already.
+func renewEffect(p *player.Player, t effect.LastingType, lvl int) {
+	if lvl <= 0 {
+		return
+	}
+	if cur, ok := p.Effect(t); ok && (cur.Level() > lvl || cur.Infinite() || cur.Duration() > islandEffect/2) {
+		return
+	}
+	p.AddEffect(effect.NewAmbient(t, lvl, islandEffect))
+}
+
+// nearTrap checks if pos lies on the island of an enemy team of pd that has
+// traps set. The arena's mutex must be held.
+func (a *Arena) nearTrap(pd *PlayerData, pos mgl64.Vec3) bool {
+	radius := float64(a.global.Upgrades.Radius)
+	for _, t := range a.Teams {
+		if t != pd.Team && len(t.Traps()) > 0 && onIsland(pos, t.Spawn, radius) {
+			return true
+		}
+	}
+	return false
+}
+
+// triggerTraps springs the first trap of every enemy team of p whose island
+// p is on at pos, and warns those teams.
+func (a *Arena) triggerTraps(p *player.Player, pos mgl64.Vec3) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pd, ok := a.Players[p.Name()]
+	if !ok || pd.Team == nil || !pd.IsAlive || !a.State.InGame() {
+		return
+	}
+	radius, cooldown := float64(a.global.Upgrades.Radius), seconds(a.global.Upgrades.TrapCooldown)
+	for _, t := range a.Teams {
+		if t == pd.Team || !onIsland(pos, t.Spawn, radius) {
+			continue
+		}
+		trap, ok := t.TriggerTrap(a.now, cooldown)
+		if !ok {
+			continue
+		}
+		springTrap(p, trap)
+		p.Message(fmt.Sprintf("§c✗ You triggered the %s §cof %s§c!", trap.Title(), t.Title()))
+
+		sub := fmt.Sprintf("§7%s §7set off your %s", a.coloredName(p.Name()), trap.Title())
+		a.teamExec(t, func(p *player.Player) {
+			p.SendTitle(title.New("§c§lTRAP TRIGGERED!").WithSubtitle(sub))
+			p.PlaySound(sound.GhastWarning{})
+		})
+	}
+}
+
+// springTrap applies the effects of trap to p.
+func springTrap(p *player.Player, trap team.Trap) {
+	switch trap {
+	case team.BlindnessTrap:
+		p.AddEffect(effect.New(effect.Blindness, 1, 8*time.Second))
+		p.AddEffect(effect.New(effect.Slowness, 1, 8*time.Second))
+	case team.FatigueTrap:
+		p.AddEffect(effect.New(effect.MiningFatigue, 1, 10*time.Second))
+	case team.AlarmTrap:
+		p.RemoveEffect(effect.Invisibility)
+	}
+}
+
+// onIsland checks if pos is within radius blocks of spawn, not counting
+// height.
+func onIsland(pos, spawn mgl64.Vec3, radius float64) bool {
+	d := pos.Sub(spawn)
+	return d[0]*d[0]+d[2]*d[2] <= radius*radius
+}
+
+// teamExec queues f to be run for every player of t. The arena's mutex must
+// be held.
+func (a *Arena) teamExec(t *team.Team, f func(p *player.Player)) {
+	for _, name := range t.Players {
+		if pd, ok := a.Players[name]; ok {
+			a.exec(pd, f)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package arena
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/utility"
+
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/dragonfly/server/world/sound"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// platform is a rescue platform spawned during the match.
+type platform struct {
+	blocks []cube.Pos
+	until  time.Time
+}
+
+// UseUtility uses the special item held by p. at is where the item would be
+// placed if p used it on a block, or nil if p used it in the air. UseUtility
+// returns false if held is not a special item, in which case it is used as
+// usual.
+func (a *Arena) UseUtility(p *player.Player, held item.Stack, at *cube.Pos) bool {
+	it, ok := held.Item().(utility.Item)
+	tracker := utility.IsTracker(held)
+	if !ok && !tracker {
+		return false
+	}
+	a.mu.RLock()
+	pd := a.Players[p.Name()]
+	usable := pd != nil && pd.Team != nil && pd.IsAlive && !pd.Spectator && a.State.InGame() && p.Tx().World() == a.World
+	a.mu.RUnlock()
+	// Using an item on a block does not check for cooldowns the way using it
+	// in the air does.
+	if !usable || p.HasCooldown(held.Item()) {
+		return true
+	}
+
+	tx, used, consumed, cooldown := p.Tx(), false, true, utility.TrackerCooldown
+	if tracker {
+		used, consumed = a.trackEnemy(p, tx, pd.Team), false
+	} else {
+		cooldown = it.Cooldown()
+		switch it.(type) {
+		case utility.BridgeEgg:
+			used = a.throwBridgeEgg(p, tx, pd.Team.Color)
+		case utility.InstantTNT:
+			used = at != nil && a.placeInstantTNT(p, tx, *at)
+		case utility.Fireball:
+			used = throwFireball(p, tx)
+		case utility.RescuePlatform:
+			used = a.spawnPlatform(p, tx)
+		}
+	}
+	if !used {
+		return true
+	}
+	p.SetCooldown(held.Item(), cooldown)
+	if consumed {
+		_, left := p.HeldItems()
+		p.SetHeldItems(held.Grow(-1), left)
+	}
+	return true
+}
+
+// eyePosition returns the position of the eyes of p.
+func eyePosition(p *player.Player) mgl64.Vec3 {
+	return p.Position().Add(mgl64.Vec3{0, p.EyeHeight()})
+}
+
+// throwBridgeEgg throws a bridge egg for p, which places wool of the colour c
+// below itself as it flies.
+func (a *Arena) throwBridgeEgg(p *player.Player, tx *world.Tx, c team.Color) bool {
+	opts := world.EntitySpawnOpts{Position: eyePosition(p), Velocity: p.Rotation().Vec3().Mul(1.5)}
+	h := entity.NewEgg(opts, p)
+	tx.AddEntity(h)
+	tx.PlaySound(p.Position(), sound.ItemThrow{})
+	go a.bridge(tx.World(), h, block.Wool{Colour: c.Dye()})
+	return true
+}
+
+// bridge places wool below the bridge egg h every tick until the egg breaks,
+// it flew for utility.BridgeDuration or it placed utility.BridgeLength blocks.
+func (a *Arena) bridge(w *world.World, h *world.EntityHandle, wool block.Wool) {
+	ticker := time.NewTicker(time.Second / 20)
+	defer ticker.Stop()
+
+	var (
+		last    mgl64.Vec3
+		started bool
+		placed  int
+		done    bool
+	)
+	for range int(utility.BridgeDuration / (time.Second / 20)) {
+		select {
+		case <-ticker.C:
+		case <-a.closing:
+			return
+		}
+		<-w.Exec(func(tx *world.Tx) {
+			e, ok := h.Entity(tx)
+			if !ok {
+				done = true
+				return
+			}
+			pos := e.Position()
+			if !started {
+				last, started = pos, true
+			}
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			if !a.State.InGame() {
+				done = true
+				return
+			}
+			// The egg may move more than a block every tick, so blocks are
+			// placed along the whole way it moved to leave no gaps.
+			steps := int(math.Ceil(pos.Sub(last).Len()*2)) + 1
+			for i := range steps {
+				// Blocks are placed two blocks below the egg, which is level
+				// with the block the thrower stood on.
+				at := cube.PosFromVec3(last.Add(pos.Sub(last).Mul(float64(i) / float64(steps)))).Sub(cube.Pos{0, 2})
+				if a.placeUtility(tx, at, wool) {
+					tx.PlaySound(at.Vec3Centre(), sound.BlockPlace{Block: wool})
+					if placed++; placed >= utility.BridgeLength {
+						done = true
+						return
+					}
+				}
+			}
+			last = pos
+		})
+		if done {
+			return
+		}
+	}
+}
+
+// placeUtility places b at pos for a special item. Like blocks placed by
+// players, the block is rolled back after the match and may be broken by
+// anyone. It returns false if pos is not empty or blocks may not be placed
+// there. The arena's mutex must be held.
+func (a *Arena) placeUtility(tx *world.Tx, pos cube.Pos, b world.Block) bool {
+	if tx.World() != a.World || a.buildProblem(pos) != "" {
+		return false
+	}
+	if _, ok := tx.Block(pos).(block.Air); !ok {
+		return false
+	}
+	a.Journal.Record(tx, pos)
+	tx.SetBlock(pos, b, nil)
+	a.PlacedBlocks[pos] = true
+	return true
+}
+
+// placeInstantTNT spawns lit TNT at pos for p.
+func (a *Arena) placeInstantTNT(p *player.Player, tx *world.Tx, pos cube.Pos) bool {
+	if _, ok := tx.Block(pos).(block.Air); !ok {
+		return false
+	}
+	a.mu.RLock()
+	msg := a.buildProblem(pos)
+	a.mu.RUnlock()
+	if msg != "" {
+		p.Message(msg)
+		return false
+	}
+	tx.AddEntity(entity.NewTNT(world.EntitySpawnOpts{Position: pos.Vec3Middle()}, utility.TNTFuse))
+	tx.PlaySound(pos.Vec3Centre(), sound.TNT{})
+	return true
+}
+
+// throwFireball throws a fireball in the direction p looks.
+func throwFireball(p *player.Player, tx *world.Tx) bool {
+	dir := p.Rotation().Vec3()
+	opts := world.EntitySpawnOpts{Position: eyePosition(p).Add(dir), Velocity: dir.Mul(utility.FireballSpeed)}
+	tx.AddEntity(utility.NewFireball(opts, p))
+	tx.PlaySound(p.Position(), sound.FireCharge{})
+	return true
+}
+
+// spawnPlatform spawns a rescue platform of slime two blocks below p, which
+// must be falling.
+func (a *Arena) spawnPlatform(p *player.Player, tx *world.Tx) bool {
+	if p.OnGround() {
+		p.Message("§c✗ You can only use a rescue platform while falling!")
+		return false
+	}
+	centre := cube.PosFromVec3(p.Position()).Sub(cube.Pos{0, 2})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var blocks []cube.Pos
+	for x := -utility.PlatformRadius; x <= utility.PlatformRadius; x++ {
+		for z := -utility.PlatformRadius; z <= utility.PlatformRadius; z++ {
+			if pos := centre.Add(cube.Pos{x, 0, z}); a.placeUtility(tx, pos, utility.Slime{}) {
+				blocks = append(blocks, pos)
+			}
+		}
+	}
+	if len(blocks) == 0 {
+		p.Message("§c✗ There is no room for a rescue platform here!")
+		return false
+	}
+	a.platforms = append(a.platforms, platform{blocks: blocks, until: a.now.Add(utility.PlatformDuration)})
+	tx.PlaySound(centre.Vec3Centre(), sound.BlockPlace{Block: utility.Slime{}})
+	return true
+}
+
+// tickPlatforms removes the rescue platforms that lasted long enough. The
+// arena's mutex must be held.
+func (a *Arena) tickPlatforms(now time.Time) {
+	var expired []cube.Pos
+	kept := a.platforms[:0]
+	for _, pl := range a.platforms {
+		if now.Before(pl.until) {
+			kept = append(kept, pl)
+			continue
+		}
+		for _, pos := range pl.blocks {
+			delete(a.PlacedBlocks, pos)
+		}
+		expired = append(expired, pl.blocks...)
+	}
+	a.platforms = kept
+	if len(expired) == 0 {
+		return
+	}
+	w := a.World
+	a.later(func() {
+		<-w.Exec(func(tx *world.Tx) {
+			for _, pos := range expired {
+				// The block may have been broken or replaced meanwhile.
+				if _, ok := tx.Block(pos).(utility.Slime); ok {
+					tx.SetBlock(pos, nil, nil)
+				}
+			}
+		})
+	})
+}
+
+// trackEnemy points the compass of p at the nearest player of another team and
+// shows p their name and distance. It returns false if there is none. Compasses
+// are pointed by sending p another world spawn, which affects all compasses of
+// p until it leaves the world or becomes a spectator.
+func (a *Arena) trackEnemy(p *player.Player, tx *world.Tx, own *team.Team) bool {
+	a.mu.RLock()
+	var (
+		nearest string
+		pos     mgl64.Vec3
+		dist    = math.Inf(1)
+		colour  team.Color
+	)
+	for other := range tx.Players() {
+		pd, ok := a.Players[other.(*player.Player).Name()]
+		if !ok || pd.Team == nil || pd.Team == own || !pd.IsAlive || pd.Spectator {
+			continue
+		}
+		if d := other.Position().Sub(p.Position()).Len(); d < dist {
+			nearest, pos, dist, colour = pd.Player.Name(), other.Position(), d, pd.Team.Color
+		}
+	}
+	a.mu.RUnlock()
+
+	if nearest == "" {
+		p.SendTip("§c✗ No enemies left to track.")
+		return false
+	}
+	p.SetCompassTarget(cube.PosFromVec3(pos))
+	p.SendTip(fmt.Sprintf("§7Nearest enemy: %s%s §7- §f%d blocks", colour.Code(), nearest, int(dist)))
+	return true
+}
+
+// tickTrackers keeps the trackers held by players pointed at the nearest
+// enemy. The arena's mutex must be held when calling tickTrackers.
+func (a *Arena) tickTrackers() {
+	w := a.World
+	for _, pd := range a.Players {
+		if pd.Team == nil || !pd.IsAlive || pd.Spectator || !pd.respawnAt.IsZero() || !pd.rejoinBy.IsZero() {
+			continue
+		}
+		own := pd.Team
+		a.exec(pd, func(p *player.Player) {
+			held, _ := p.HeldItems()
+			if utility.IsTracker(held) && p.Tx().World() == w {
+				a.trackEnemy(p, p.Tx(), own)
+			}
+		})
+	}
+}
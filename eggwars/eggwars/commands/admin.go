@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"github.com/eggwars-dragonfly/eggwars/eggwars/setup"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// registerAdminCommands registers /ewadmin, used to set up arenas in-game and
+// to look back at past matches.
+func registerAdminCommands() {
+	cmd.Register(cmd.New("ewadmin", "Set up EggWars arenas (saving rewrites arenas.toml without its comments)", []string{},
+		AdminCreateCommand{}, AdminEditCommand{}, AdminWorldCommand{}, AdminLobbyCommand{},
+		AdminTeamCommand{}, AdminSpawnCommand{}, AdminEggCommand{}, AdminNPCCommand{}, AdminGeneratorCommand{},
+		AdminPos1Command{}, AdminPos2Command{}, AdminVoidCommand{}, AdminHeightCommand{},
+		AdminPlayersCommand{}, AdminValidateCommand{},
+		AdminSaveCommand{}, AdminCancelCommand{}, AdminInfoCommand{},
+		AdminMatchesCommand{}, AdminMatchCommand{},
+	))
+}
+
+// admin is embedded in every /ewadmin command. It only allows admins to run
+// them.
+type admin struct{}
+
+func (admin) Allow(src cmd.Source) bool {
+	p, ok := src.(*player.Player)
+	return ok && globalGameManager != nil && globalGameManager.IsAdmin(p)
+}
+
+// wizard returns the player running the command and the setup wizard.
+func wizard(src cmd.Source) (*player.Player, *setup.Wizard) {
+	return src.(*player.Player), globalGameManager.Setup()
+}
+
+// TeamColour is a colour a team may be set up with.
+type TeamColour string
+
+func (TeamColour) Type() string { return "TeamColour" }
+
+func (TeamColour) Options(cmd.Source) []string {
+	opts := make([]string, len(team.Colors))
+	for i, c := range team.Colors {
+		opts[i] = string(c)
+	}
+	return opts
+}
+
+// GeneratorType is the type of a generator.
+type GeneratorType string
+
+func (GeneratorType) Type() string { return "GeneratorType" }
+
+func (GeneratorType) Options(cmd.Source) []string { return setup.GeneratorTypes }
+
+// NPCKind is the kind of a shopkeeper.
+type NPCKind string
+
+func (NPCKind) Type() string { return "NPCKind" }
+
+func (NPCKind) Options(cmd.Source) []string { return setup.NPCKinds }
+
+type AdminCreateCommand struct {
+	admin
+	Sub  cmd.SubCommand `cmd:"create"`
+	Name string         `cmd:"name"`
+}
+
+func (c AdminCreateCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.Create(p, c.Name)
+}
+
+type AdminEditCommand struct {
+	admin
+	Sub  cmd.SubCommand `cmd:"edit"`
+	Name string         `cmd:"name"`
+}
+
+func (c AdminEditCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.Edit(p, c.Name)
+}
+
+type AdminWorldCommand struct {
+	admin
+	Sub  cmd.SubCommand `cmd:"world"`
+	Name string         `cmd:"name"`
+}
+
+func (c AdminWorldCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetWorld(p, c.Name)
+}
+
+type AdminLobbyCommand struct {
+	admin
+	Sub cmd.SubCommand `cmd:"lobby"`
+}
+
+func (c AdminLobbyCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetLobby(p)
+}
+
+type AdminTeamCommand struct {
+	admin
+	Sub    cmd.SubCommand `cmd:"team"`
+	Colour TeamColour     `cmd:"colour"`
+}
+
+func (c AdminTeamCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.AddTeam(p, string(c.Colour))
+}
+
+type AdminSpawnCommand struct {
+	admin
+	Sub    cmd.SubCommand `cmd:"spawn"`
+	Colour TeamColour     `cmd:"colour"`
+}
+
+func (c AdminSpawnCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetTeamSpawn(p, string(c.Colour))
+}
+
+type AdminEggCommand struct {
+	admin
+	Sub    cmd.SubCommand `cmd:"egg"`
+	Colour TeamColour     `cmd:"colour"`
+}
+
+func (c AdminEggCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetEgg(p, string(c.Colour))
+}
+
+type AdminNPCCommand struct {
+	admin
+	Sub    cmd.SubCommand `cmd:"npc"`
+	Kind   NPCKind        `cmd:"kind"`
+	Colour TeamColour     `cmd:"colour"`
+}
+
+func (c AdminNPCCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetNPC(p, string(c.Kind), string(c.Colour))
+}
+
+type AdminGeneratorCommand struct {
+	admin
+	Sub  cmd.SubCommand           `cmd:"generator"`
+	Kind GeneratorType            `cmd:"type"`
+	Team cmd.Optional[TeamColour] `cmd:"team"`
+}
+
+func (c AdminGeneratorCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.AddGenerator(p, string(c.Kind), string(c.Team.LoadOr("")))
+}
+
+type AdminPos1Command struct {
+	admin
+	Sub cmd.SubCommand `cmd:"pos1"`
+}
+
+func (c AdminPos1Command) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetCorner(p, 0)
+}
+
+type AdminPos2Command struct {
+	admin
+	Sub cmd.SubCommand `cmd:"pos2"`
+}
+
+func (c AdminPos2Command) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetCorner(p, 1)
+}
+
+type AdminVoidCommand struct {
+	admin
+	Sub cmd.SubCommand `cmd:"void"`
+}
+
+func (c AdminVoidCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetVoid(p)
+}
+
+type AdminHeightCommand struct {
+	admin
+	Sub cmd.SubCommand `cmd:"height"`
+}
+
+func (c AdminHeightCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetBuildHeight(p)
+}
+
+type AdminPlayersCommand struct {
+	admin
+	Sub      cmd.SubCommand `cmd:"players"`
+	Min      int            `cmd:"min"`
+	TeamSize int            `cmd:"team_size"`
+}
+
+func (c AdminPlayersCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.SetPlayers(p, c.Min, c.TeamSize)
+}
+
+type AdminValidateCommand struct {
+	admin
+	Sub cmd.SubCommand `cmd:"validate"`
+}
+
+func (c AdminValidateCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.Validate(p)
+}
+
+// AdminSaveCommand saves the arena being set up. The configuration file is
+// written anew, so comments in it are lost.
+type AdminSaveCommand struct {
+	admin
+	Sub cmd.SubCommand `cmd:"save"`
+}
+
+func (c AdminSaveCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.Save(p)
+}
+
+type AdminCancelCommand struct {
+	admin
+	Sub cmd.SubCommand `cmd:"cancel"`
+}
+
+func (c AdminCancelCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.Cancel(p)
+}
+
+type AdminInfoCommand struct {
+	admin
+	Sub cmd.SubCommand `cmd:"info"`
+}
+
+func (c AdminInfoCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, wz := wizard(src)
+	wz.Info(p)
+}
+
+type AdminMatchesCommand struct {
+	admin
+	Sub cmd.SubCommand `cmd:"matches"`
+}
+
+func (c AdminMatchesCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	globalGameManager.ListMatches(src.(*player.Player))
+}
+
+type AdminMatchCommand struct {
+	admin
+	Sub cmd.SubCommand `cmd:"match"`
+	ID  string         `cmd:"id"`
+}
+
+func (c AdminMatchCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	globalGameManager.ShowMatch(src.(*player.Player), c.ID)
+}
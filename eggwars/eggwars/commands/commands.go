@@ -1,6 +1,10 @@
 package commands
 
 import (
+        "github.com/eggwars-dragonfly/eggwars/eggwars/party"
+        "github.com/eggwars-dragonfly/eggwars/eggwars/setup"
+        "github.com/eggwars-dragonfly/eggwars/eggwars/stats"
+
         "github.com/df-mc/dragonfly/server/cmd"
         "github.com/df-mc/dragonfly/server/player"
         "github.com/df-mc/dragonfly/server/world"
@@ -14,8 +18,16 @@ type GameManager interface {
         Log() interface{}
         JoinArena(p *player.Player, arenaName string) bool
         LeaveArena(p *player.Player) bool
+        RejoinArena(p *player.Player) bool
+        SpectateArena(p *player.Player, arenaName string) bool
         ListArenas(p *player.Player)
         ShowStats(p *player.Player)
+        ShowTop(p *player.Player, stat, window string)
+        IsAdmin(p *player.Player) bool
+        ListMatches(p *player.Player)
+        ShowMatch(p *player.Player, id string)
+        Setup() *setup.Wizard
+        Parties() *party.Manager
 }
 
 var globalGameManager GameManager
@@ -25,8 +37,13 @@ func RegisterCommands(gm GameManager) {
         
         cmd.Register(cmd.New("join", "Join an EggWars arena", []string{}, JoinArenaCommand{}))
         cmd.Register(cmd.New("leave", "Leave current arena", []string{}, LeaveArenaCommand{}))
+        cmd.Register(cmd.New("rejoin", "Go back to the match you disconnected from", []string{}, RejoinArenaCommand{}))
+        cmd.Register(cmd.New("spectate", "Watch a running EggWars game", []string{}, SpectateArenaCommand{}))
         cmd.Register(cmd.New("arenas", "List all arenas", []string{}, ListArenasCommand{}))
         cmd.Register(cmd.New("ewstats", "Show your statistics", []string{}, StatsShowCommand{}))
+        cmd.Register(cmd.New("ewtop", "Show the best players", []string{}, TopCommand{}))
+        registerPartyCommands()
+        registerAdminCommands()
 }
 
 type EggWarsCommand struct {
@@ -77,6 +94,36 @@ func (l LeaveArenaCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
         }
 }
 
+type RejoinArenaCommand struct{}
+
+func (r RejoinArenaCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+        p, ok := src.(*player.Player)
+        if !ok {
+                o.Error("Only players can use this command")
+                return
+        }
+
+        if globalGameManager != nil {
+                globalGameManager.RejoinArena(p)
+        }
+}
+
+type SpectateArenaCommand struct {
+        Arena string `cmd:"arena"`
+}
+
+func (s SpectateArenaCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+        p, ok := src.(*player.Player)
+        if !ok {
+                o.Error("Only players can use this command")
+                return
+        }
+        
+        if globalGameManager != nil {
+                globalGameManager.SpectateArena(p, s.Arena)
+        }
+}
+
 type ListArenasCommand struct{}
 
 func (l ListArenasCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
@@ -104,3 +151,46 @@ func (s StatsShowCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
                 globalGameManager.ShowStats(p)
         }
 }
+
+// LeaderboardStat is a stat that players may be ranked by.
+type LeaderboardStat string
+
+func (LeaderboardStat) Type() string { return "Stat" }
+
+func (LeaderboardStat) Options(cmd.Source) []string {
+        opts := make([]string, len(stats.Stats))
+        for i, s := range stats.Stats {
+                opts[i] = string(s)
+        }
+        return opts
+}
+
+// LeaderboardWindow is a time frame that players may be ranked over.
+type LeaderboardWindow string
+
+func (LeaderboardWindow) Type() string { return "Window" }
+
+func (LeaderboardWindow) Options(cmd.Source) []string {
+        opts := make([]string, len(stats.Windows))
+        for i, w := range stats.Windows {
+                opts[i] = string(w)
+        }
+        return opts
+}
+
+type TopCommand struct {
+        Stat   LeaderboardStat                 `cmd:"stat"`
+        Window cmd.Optional[LeaderboardWindow] `cmd:"window"`
+}
+
+func (t TopCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+        p, ok := src.(*player.Player)
+        if !ok {
+                o.Error("Only players can use this command")
+                return
+        }
+
+        if globalGameManager != nil {
+                globalGameManager.ShowTop(p, string(t.Stat), string(t.Window.LoadOr(LeaderboardWindow(stats.AllTime))))
+        }
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/party"
+
+	"github.com/df-mc/dragonfly/server/cmd"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// registerPartyCommands registers /party, used to play together with friends.
+func registerPartyCommands() {
+	cmd.Register(cmd.New("party", "Play together with friends", []string{"p"},
+		PartyInviteCommand{}, PartyAcceptCommand{}, PartyDenyCommand{}, PartyKickCommand{},
+		PartyLeaveCommand{}, PartyDisbandCommand{}, PartyListCommand{}, PartyChatCommand{},
+		PartyTransferCommand{},
+	))
+}
+
+// partyPlayer is embedded in every /party command. It only allows players to
+// run them.
+type partyPlayer struct{}
+
+func (partyPlayer) Allow(src cmd.Source) bool {
+	_, ok := src.(*player.Player)
+	return ok && globalGameManager != nil
+}
+
+// parties returns the player running the command and the party manager.
+func parties(src cmd.Source) (*player.Player, *party.Manager) {
+	return src.(*player.Player), globalGameManager.Parties()
+}
+
+type PartyInviteCommand struct {
+	partyPlayer
+	Sub     cmd.SubCommand `cmd:"invite"`
+	Targets []cmd.Target   `cmd:"player"`
+}
+
+func (c PartyInviteCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, m := parties(src)
+	for _, t := range c.Targets {
+		if target, ok := t.(*player.Player); ok {
+			m.Invite(p, target.Name())
+		}
+	}
+}
+
+type PartyAcceptCommand struct {
+	partyPlayer
+	Sub  cmd.SubCommand       `cmd:"accept"`
+	From cmd.Optional[string] `cmd:"player"`
+}
+
+func (c PartyAcceptCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, m := parties(src)
+	m.Accept(p, c.From.LoadOr(""))
+}
+
+type PartyDenyCommand struct {
+	partyPlayer
+	Sub  cmd.SubCommand       `cmd:"deny"`
+	From cmd.Optional[string] `cmd:"player"`
+}
+
+func (c PartyDenyCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, m := parties(src)
+	m.Deny(p, c.From.LoadOr(""))
+}
+
+type PartyKickCommand struct {
+	partyPlayer
+	Sub    cmd.SubCommand `cmd:"kick"`
+	Player string         `cmd:"player"`
+}
+
+func (c PartyKickCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, m := parties(src)
+	m.Kick(p, c.Player)
+}
+
+type PartyLeaveCommand struct {
+	partyPlayer
+	Sub cmd.SubCommand `cmd:"leave"`
+}
+
+func (c PartyLeaveCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, m := parties(src)
+	m.Leave(p)
+}
+
+type PartyDisbandCommand struct {
+	partyPlayer
+	Sub cmd.SubCommand `cmd:"disband"`
+}
+
+func (c PartyDisbandCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, m := parties(src)
+	m.Disband(p)
+}
+
+type PartyListCommand struct {
+	partyPlayer
+	Sub cmd.SubCommand `cmd:"list"`
+}
+
+func (c PartyListCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, m := parties(src)
+	m.List(p)
+}
+
+type PartyChatCommand struct {
+	partyPlayer
+	Sub     cmd.SubCommand `cmd:"chat"`
+	Message cmd.Varargs    `cmd:"message"`
+}
+
+func (c PartyChatCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, m := parties(src)
+	if msg := strings.TrimSpace(string(c.Message)); msg != "" {
+		m.Chat(p, msg)
+	}
+}
+
+type PartyTransferCommand struct {
+	partyPlayer
+	Sub    cmd.SubCommand `cmd:"transfer"`
+	Player string         `cmd:"player"`
+}
+
+func (c PartyTransferCommand) Run(src cmd.Source, o *cmd.Output, tx *world.Tx) {
+	p, m := parties(src)
+	m.Transfer(p, c.Player)
+}
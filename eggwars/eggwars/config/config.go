@@ -1,154 +1,853 @@
 package config
 
 import (
-        "github.com/df-mc/dragonfly/server/block/cube"
-        "github.com/go-gl/mathgl/mgl64"
-        "github.com/pelletier/go-toml/v2"
-        "github.com/sirupsen/logrus"
-        "os"
+	"fmt"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"slices"
 )
 
 type Config struct {
-        Arenas map[string]*ArenaConfig `toml:"arenas"`
-        Shop   *ShopConfig             `toml:"shop"`
+	// MapsDir is the directory holding the template maps that arenas are
+	// played on. ArenaConfig.World names a directory inside it.
+	MapsDir string `toml:"maps_dir"`
+	// InstancesDir is the directory that template maps are copied to while a
+	// match is running. Copies are removed again once the match is over,
+	// unless ReuseInstances is set.
+	InstancesDir string `toml:"instances_dir"`
+	// ReuseInstances makes arenas keep their copy of the template map between
+	// matches and restore it by rolling back the blocks recorded during the
+	// match, rather than copying the template again. This resets arenas
+	// faster, but changes that are not recorded, such as ones made by plugins
+	// or to entities saved in the map, carry over to later matches.
+	ReuseInstances bool `toml:"reuse_instances"`
+	// MatchesDir is the directory that the event log of every match is
+	// written to once it is over.
+	MatchesDir string                  `toml:"matches_dir"`
+	Arenas     map[string]*ArenaConfig `toml:"arenas"`
+	Shop       *ShopConfig             `toml:"shop"`
+	Stats      StatsConfig             `toml:"stats"`
+	// Leaderboards configures the leaderboard holograms in the lobby.
+	Leaderboards LeaderboardsConfig `toml:"leaderboards"`
+	Party        PartyConfig        `toml:"party"`
+	// Kits maps the IDs of the kits players may pick to their
+	// configuration.
+	Kits    map[string]*KitConfig `toml:"kits"`
+	Rewards RewardsConfig         `toml:"rewards"`
+	// Upgrades configures the team upgrades sold by the upgrade shopkeepers.
+	Upgrades UpgradesConfig `toml:"upgrades"`
+	// NPCs configures the shopkeepers standing on the islands of every team.
+	NPCs   NPCsConfig   `toml:"npcs"`
+	Combat CombatConfig `toml:"combat"`
+	// Admins holds the names or XUIDs of the players allowed to use the
+	// /ewadmin commands.
+	Admins []string `toml:"admins"`
+}
+
+// UpgradesConfig configures the team upgrades. The costs of upgrades hold the
+// price of each of their tiers. Upgrades without tiers are not sold.
+type UpgradesConfig struct {
+	// Currency is the currency that team upgrades are paid with.
+	Currency     string `toml:"currency"`
+	Sharpness    []int  `toml:"sharpness"`
+	Protection   []int  `toml:"protection"`
+	Haste        []int  `toml:"haste"`
+	Regeneration []int  `toml:"regeneration"`
+	Forge        []int  `toml:"forge"`
+	// ForgeSpeed is how much faster every tier of the forge makes the
+	// generators of a team, such as 0.25 for 25% faster.
+	ForgeSpeed float64 `toml:"forge_speed"`
+	// Traps holds the price of the first, second and third trap set at the
+	// same time.
+	Traps []int `toml:"traps"`
+	// Radius is the distance, in blocks, from its spawn up to which a team's
+	// island reaches. Haste and regeneration only work on the island, and
+	// traps trigger when enemies enter it.
+	Radius int `toml:"radius"`
+	// TrapCooldown is the time, in seconds, before the next trap of a team
+	// may trigger after one triggered.
+	TrapCooldown int `toml:"trap_cooldown"`
+}
+
+// Costs returns the price of every tier of the upgrade with the name passed.
+func (u UpgradesConfig) Costs(upgrade string) []int {
+	switch upgrade {
+	case "sharpness":
+		return u.Sharpness
+	case "protection":
+		return u.Protection
+	case "haste":
+		return u.Haste
+	case "regeneration":
+		return u.Regeneration
+	case "forge":
+		return u.Forge
+	}
+	return nil
+}
+
+// NPCsConfig configures the look of the shopkeepers.
+type NPCsConfig struct {
+	// Skin is the path to a 64x64 or 128x128 PNG skin worn by all
+	// shopkeepers. Shopkeepers wear a plain skin in their team's colour if
+	// it is empty.
+	Skin         string `toml:"skin,omitempty"`
+	ShopName     string `toml:"shop_name"`
+	UpgradesName string `toml:"upgrades_name"`
+}
+
+// CombatConfig configures how kills are credited.
+type CombatConfig struct {
+	// TagWindow is the time, in seconds, a player stays tagged as being in
+	// combat with a player that hit it. A player dying to the void, falling
+	// or fire within this time counts as killed by the player that hit it
+	// last, and earlier attackers within it get an assist.
+	TagWindow int `toml:"tag_window"`
+}
+
+// StatsConfig configures where the stats of players are stored.
+type StatsConfig struct {
+	// Backend is the storage backend used, either "json", "leveldb" or
+	// "memory".
+	Backend string `toml:"backend"`
+	// Path is the file (json) or directory (leveldb) stats are stored in.
+	Path string `toml:"path"`
+	// FlushInterval is the interval, in seconds, at which changed stats are
+	// written to the backend.
+	FlushInterval int `toml:"flush_interval"`
+	// InitialRating is the skill rating that players start with.
+	InitialRating float64 `toml:"initial_rating"`
+	// RatingK is the most a player's rating may change by in a single
+	// match.
+	RatingK float64 `toml:"rating_k"`
+}
+
+// KitConfig configures a kit: a set of items and effects that players get
+// every time they spawn in a match.
+type KitConfig struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description,omitempty"`
+	// Icon is the path of the texture shown on the kit's button, such as
+	// "textures/items/iron_sword".
+	Icon string `toml:"icon,omitempty"`
+	// Cost is the amount of coins needed to unlock the kit. Kits that cost
+	// nothing may be used by everyone.
+	Cost int `toml:"cost,omitempty"`
+	// Default makes the kit the one used by players that did not pick a kit.
+	// It should be set for a kit that costs nothing.
+	Default bool `toml:"default,omitempty"`
+	// Helmet, Chestplate, Leggings and Boots are the armour of the kit. Slots
+	// left empty are filled with the team armour.
+	Helmet     *KitItem `toml:"helmet,omitempty"`
+	Chestplate *KitItem `toml:"chestplate,omitempty"`
+	Leggings   *KitItem `toml:"leggings,omitempty"`
+	Boots      *KitItem `toml:"boots,omitempty"`
+	// Items are put in the hotbar in order.
+	Items   []*KitItem   `toml:"items,omitempty"`
+	Effects []*KitEffect `toml:"effects,omitempty"`
+}
+
+// KitItem is a single item of a kit.
+type KitItem struct {
+	Item   string `toml:"item"`
+	Meta   int16  `toml:"meta,omitempty"`
+	Amount int    `toml:"amount,omitempty"`
+	// Enchantments maps enchantment names, such as "sharpness", to levels.
+	Enchantments map[string]int `toml:"enchantments,omitempty"`
+	CustomName   string         `toml:"custom_name,omitempty"`
+}
+
+// KitEffect is an effect that players of a kit get when they spawn.
+type KitEffect struct {
+	// Type is the name of the effect, such as "speed" or "jump_boost".
+	Type  string `toml:"type"`
+	Level int    `toml:"level"`
+	// Duration is the time, in seconds, the effect lasts. Effects without a
+	// duration last until the player dies.
+	Duration int `toml:"duration,omitempty"`
+}
+
+// RewardsConfig holds the coins players earn in a match, which are spent to
+// unlock kits.
+type RewardsConfig struct {
+	Game int `toml:"game"`
+	Kill int `toml:"kill"`
+	Egg  int `toml:"egg"`
+	Win  int `toml:"win"`
+}
+
+// PartyConfig configures parties of players that play together.
+type PartyConfig struct {
+	// MaxSize is the maximum amount of players in a party, including its
+	// leader.
+	MaxSize int `toml:"max_size"`
+	// InviteTimeout is the time, in seconds, after which an invite to a
+	// party expires.
+	InviteTimeout int `toml:"invite_timeout"`
+}
+
+// LeaderboardsConfig configures the leaderboards shown in the lobby.
+type LeaderboardsConfig struct {
+	// Refresh is the interval, in seconds, at which the leaderboards are
+	// updated.
+	Refresh   int               `toml:"refresh"`
+	Holograms []*HologramConfig `toml:"holograms"`
+}
+
+// HologramConfig configures a leaderboard shown as floating text in the
+// lobby.
+type HologramConfig struct {
+	// Stat is the stat players are ranked by: "rating", "wins", "kills" or
+	// "kd".
+	Stat string `toml:"stat"`
+	// Window is the time frame players are ranked over: "all", "weekly" or
+	// "daily".
+	Window string `toml:"window"`
+	// Size is the amount of players shown.
+	Size int        `toml:"size"`
+	Pos  mgl64.Vec3 `toml:"pos"`
 }
 
 type ArenaConfig struct {
-        World       string                  `toml:"world"`
-        MinPlayers  int                     `toml:"min_players"`
-        MaxPlayers  int                     `toml:"max_players"`
-        LobbySpawn  mgl64.Vec3              `toml:"lobby_spawn"`
-        Teams       map[string]*TeamConfig  `toml:"teams"`
+	World      string `toml:"world"`
+	MinPlayers int    `toml:"min_players"`
+	// TeamSize is the maximum amount of players in a team: 1 for solo, 2 for
+	// doubles and 4 for squads.
+	TeamSize int `toml:"team_size"`
+	// MaxPlayers is the amount of teams times TeamSize. It is not configured
+	// but filled in when the configuration is loaded.
+	MaxPlayers int        `toml:"-"`
+	LobbySpawn mgl64.Vec3 `toml:"lobby_spawn"`
+	// SpectatorSpawn is the position in the arena's world that spectators
+	// are sent to when they start watching a match.
+	SpectatorSpawn mgl64.Vec3 `toml:"spectator_spawn"`
+	// Teams maps the colours of the teams, one of the 16 dye colours such as
+	// "red" or "light_blue", to their configuration.
+	Teams      map[string]*TeamConfig `toml:"teams"`
+	Generators []*GeneratorConfig     `toml:"generators"`
+	Phases     PhaseConfig            `toml:"phases"`
+	// Bounds holds two opposite corners of the area of the arena's map that
+	// matches are played in. The arena has no bounds if both are zero.
+	Bounds [2]cube.Pos `toml:"bounds"`
+	// VoidY is the Y level below which players die at once.
+	VoidY float64 `toml:"void_y"`
+	// BuildHeight is the highest Y level blocks may be placed at. It is not
+	// limited if 0.
+	BuildHeight int              `toml:"build_height"`
+	Protection  ProtectionConfig `toml:"protection"`
+	// FriendlyFire allows teammates to hurt each other.
+	FriendlyFire bool `toml:"friendly_fire"`
+}
+
+// ProtectionConfig holds the radii, in blocks, around eggs and team spawns
+// that players may not build in. A radius of 0 disables the protection.
+type ProtectionConfig struct {
+	EggRadius   int `toml:"egg_radius"`
+	SpawnRadius int `toml:"spawn_radius"`
+}
+
+// Clone returns a deep copy of the arena configuration.
+func (a *ArenaConfig) Clone() *ArenaConfig {
+	c := *a
+	c.Teams = make(map[string]*TeamConfig, len(a.Teams))
+	for name, t := range a.Teams {
+		tc := *t
+		tc.Shop, tc.Upgrades = t.Shop.Clone(), t.Upgrades.Clone()
+		c.Teams[name] = &tc
+	}
+	c.Generators = make([]*GeneratorConfig, len(a.Generators))
+	for i, g := range a.Generators {
+		gc := *g
+		gc.Intervals = slices.Clone(g.Intervals)
+		gc.Amounts = slices.Clone(g.Amounts)
+		gc.UpgradeCosts = slices.Clone(g.UpgradeCosts)
+		c.Generators[i] = &gc
+	}
+	return &c
+}
+
+// MinTeams and MaxTeams are the minimum and maximum amount of teams of an
+// arena.
+const (
+	MinTeams = 2
+	MaxTeams = 16
+)
+
+// Mode returns the name of the mode of the arena, based on its team size:
+// solo, doubles, trios, squads, or for example "5s" for larger teams.
+func (a *ArenaConfig) Mode() string {
+	switch a.TeamSize {
+	case 1:
+		return "solo"
+	case 2:
+		return "doubles"
+	case 3:
+		return "trios"
+	case 4:
+		return "squads"
+	}
+	return fmt.Sprintf("%ds", a.TeamSize)
+}
+
+func (a *ArenaConfig) applyDefaults() {
+	if a.TeamSize <= 0 {
+		a.TeamSize = 1
+	}
+	a.MaxPlayers = len(a.Teams) * a.TeamSize
+	if a.MinPlayers <= 0 {
+		a.MinPlayers = 2
+	}
+	a.Phases.applyDefaults()
+}
+
+// Bounded checks if the arena has bounds set.
+func (a *ArenaConfig) Bounded() bool {
+	return a.Bounds != [2]cube.Pos{}
+}
+
+// Corners returns the lowest and highest corner of the arena's bounds.
+func (a *ArenaConfig) Corners() (lo, hi cube.Pos) {
+	b := a.Bounds
+	return cube.Pos{min(b[0][0], b[1][0]), min(b[0][1], b[1][1]), min(b[0][2], b[1][2])},
+		cube.Pos{max(b[0][0], b[1][0]), max(b[0][1], b[1][1]), max(b[0][2], b[1][2])}
+}
+
+// Box returns the arena's bounds as a bounding box covering every block in
+// them.
+func (a *ArenaConfig) Box() cube.BBox {
+	lo, hi := a.Corners()
+	return cube.Box(float64(lo[0]), float64(lo[1]), float64(lo[2]), float64(hi[0]+1), float64(hi[1]+1), float64(hi[2]+1))
+}
+
+// InBounds checks if pos is within the arena's bounds. Every position is in
+// bounds if the arena has none.
+func (a *ArenaConfig) InBounds(pos cube.Pos) bool {
+	if !a.Bounded() {
+		return true
+	}
+	lo, hi := a.Corners()
+	return pos[0] >= lo[0] && pos[0] <= hi[0] && pos[1] >= lo[1] && pos[1] <= hi[1] && pos[2] >= lo[2] && pos[2] <= hi[2]
+}
+
+// PhaseConfig holds the durations, in seconds, of the phases of a match.
+type PhaseConfig struct {
+	// Countdown is the time between enough players joining and the match
+	// starting.
+	Countdown int `toml:"countdown"`
+	// Duration is the time a match is played normally. Once it has passed,
+	// all eggs are destroyed and sudden death starts.
+	Duration int `toml:"duration"`
+	// SuddenDeath is the time sudden death lasts before the match ends in a
+	// draw.
+	SuddenDeath int `toml:"sudden_death"`
+	// Ending is the time between the match ending and the arena resetting.
+	Ending int `toml:"ending"`
+	// Respawn is the time a player waits before respawning.
+	Respawn int `toml:"respawn"`
+	// Rejoin is the time a player that disconnected during a match has to
+	// rejoin it. Players that disconnect are removed from the match at once
+	// if it is negative.
+	Rejoin int `toml:"rejoin"`
 }
 
 type TeamConfig struct {
-        Spawn     mgl64.Vec3 `toml:"spawn"`
-        Egg       cube.Pos   `toml:"egg"`
-        Generator mgl64.Vec3 `toml:"generator"`
+	Spawn mgl64.Vec3 `toml:"spawn"`
+	Egg   cube.Pos   `toml:"egg"`
+	// Shop and Upgrades are where the item shop and upgrade shopkeepers of
+	// the team stand. Teams without one have no such shopkeeper.
+	Shop     *NPCConfig `toml:"shop,omitempty"`
+	Upgrades *NPCConfig `toml:"upgrades,omitempty"`
+}
+
+// NPCConfig is the place a shopkeeper stands at.
+type NPCConfig struct {
+	Pos mgl64.Vec3 `toml:"pos"`
+	// Yaw is the direction the shopkeeper faces, in degrees.
+	Yaw float64 `toml:"yaw"`
+}
+
+// Clone returns a copy of n. It returns nil if n is nil.
+func (n *NPCConfig) Clone() *NPCConfig {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	return &c
+}
+
+// GeneratorConfig describes a single resource generator of an arena. Tables
+// left empty use the defaults of the generator's type.
+type GeneratorConfig struct {
+	// Type is the resource dropped: iron, gold or diamond.
+	Type string `toml:"type"`
+	// Team is the team owning the generator. Generators without a team are
+	// shared and may be upgraded by anyone.
+	Team     string   `toml:"team,omitempty"`
+	Position cube.Pos `toml:"position"`
+	// Level is the level the generator starts at. Generators at level 0 are
+	// inactive until they are upgraded.
+	Level int `toml:"level"`
+	// Intervals holds the seconds between drops for each level.
+	Intervals []float64 `toml:"intervals,omitempty"`
+	// Amounts holds the amount of resources dropped at once for each level.
+	Amounts []int `toml:"amounts,omitempty"`
+	// UpgradeCosts holds the price of upgrading to each level. Every level
+	// above the starting one needs a price, which defaults to that of the
+	// generator's type for the levels the type has.
+	UpgradeCosts []int `toml:"upgrade_costs,omitempty"`
+	// UpgradeCurrency is the currency every upgrade is paid in. By default,
+	// upgrades are paid in iron, gold and diamonds as the level rises.
+	UpgradeCurrency string `toml:"upgrade_currency,omitempty"`
+	Cap             int    `toml:"cap,omitempty"`
 }
 
 type ShopConfig struct {
-        Items map[string]*ShopItem `toml:"items"`
+	Items map[string]*ShopItem `toml:"items"`
 }
 
 type ShopItem struct {
-        Name     string            `toml:"name"`
-        Price    int               `toml:"price"`
-        Currency string            `toml:"currency"`
-        Item     string            `toml:"item"`
-        Amount   int               `toml:"amount"`
+	Name string `toml:"name"`
+	// Category is the shop page the item is listed on: blocks, weapons,
+	// armour, tools, potions or utility.
+	Category string `toml:"category"`
+	Price    int    `toml:"price"`
+	Currency string `toml:"currency"`
+	Item     string `toml:"item"`
+	Meta     int16  `toml:"meta,omitempty"`
+	Amount   int    `toml:"amount"`
+	// Potion is the ID of the potion type given when Item is a potion.
+	Potion int32 `toml:"potion,omitempty"`
+	// Enchantments maps enchantment names, such as "sharpness", to levels.
+	Enchantments map[string]int `toml:"enchantments,omitempty"`
+	CustomName   string         `toml:"custom_name,omitempty"`
+	// Permanent makes the item an upgrade that players keep when they die.
+	Permanent bool `toml:"permanent,omitempty"`
 }
 
+// Path is the path of the file the configuration is loaded from and saved to.
+const Path = "arenas.toml"
+
 func LoadConfig(log *logrus.Logger) *Config {
-        if _, err := os.Stat("arenas.toml"); os.IsNotExist(err) {
-                cfg := createDefaultConfig()
-                saveConfig(cfg, "arenas.toml", log)
-                return cfg
-        }
-        
-        data, err := os.ReadFile("arenas.toml")
-        if err != nil {
-                log.Fatalf("Failed to read config: %v", err)
-        }
-        
-        var cfg Config
-        if err := toml.Unmarshal(data, &cfg); err != nil {
-                log.Fatalf("Failed to decode config: %v", err)
-        }
-        
-        return &cfg
+	if _, err := os.Stat(Path); os.IsNotExist(err) {
+		cfg := createDefaultConfig()
+		cfg.applyDefaults()
+		saveConfig(cfg, Path, log)
+		return cfg
+	}
+
+	data, err := os.ReadFile(Path)
+	if err != nil {
+		log.Fatalf("Failed to read config: %v", err)
+	}
+
+	var cfg Config
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		log.Fatalf("Failed to decode config: %v", err)
+	}
+	cfg.applyDefaults()
+
+	return &cfg
+}
+
+// TemplateDir returns the directory of the template map used by the arena
+// passed.
+func (c *Config) TemplateDir(a *ArenaConfig) string {
+	return filepath.Join(c.MapsDir, a.World)
+}
+
+func (c *Config) applyDefaults() {
+	if c.MapsDir == "" {
+		c.MapsDir = "maps"
+	}
+	if c.InstancesDir == "" {
+		c.InstancesDir = "instances"
+	}
+	if c.MatchesDir == "" {
+		c.MatchesDir = "matches"
+	}
+	for _, a := range c.Arenas {
+		a.applyDefaults()
+	}
+	if c.Stats.Backend == "" {
+		c.Stats.Backend = "json"
+	}
+	if c.Stats.Path == "" {
+		c.Stats.Path = "stats.json"
+		if c.Stats.Backend == "leveldb" {
+			c.Stats.Path = "stats"
+		}
+	}
+	if c.Stats.FlushInterval <= 0 {
+		c.Stats.FlushInterval = 10
+	}
+	if c.Stats.InitialRating <= 0 {
+		c.Stats.InitialRating = 1000
+	}
+	if c.Stats.RatingK <= 0 {
+		c.Stats.RatingK = 32
+	}
+	if c.Party.MaxSize <= 0 {
+		c.Party.MaxSize = 8
+	}
+	if c.Party.InviteTimeout <= 0 {
+		c.Party.InviteTimeout = 60
+	}
+	if c.Leaderboards.Refresh <= 0 {
+		c.Leaderboards.Refresh = 60
+	}
+	for _, h := range c.Leaderboards.Holograms {
+		if h.Window == "" {
+			h.Window = "all"
+		}
+		if h.Size <= 0 {
+			h.Size = 10
+		}
+	}
+	u := &c.Upgrades
+	if u.Currency == "" {
+		u.Currency = "diamond"
+	}
+	for _, d := range []struct {
+		costs *[]int
+		def   []int
+	}{
+		{&u.Sharpness, []int{4}},
+		{&u.Protection, []int{2, 4, 8, 16}},
+		{&u.Haste, []int{2, 4}},
+		{&u.Regeneration, []int{1}},
+		{&u.Forge, []int{2, 4, 6, 8}},
+		{&u.Traps, []int{1, 2, 4}},
+	} {
+		if *d.costs == nil {
+			*d.costs = d.def
+		}
+	}
+	if u.ForgeSpeed <= 0 {
+		u.ForgeSpeed = 0.25
+	}
+	if u.Radius <= 0 {
+		u.Radius = 15
+	}
+	if u.TrapCooldown <= 0 {
+		u.TrapCooldown = 10
+	}
+	if c.NPCs.ShopName == "" {
+		c.NPCs.ShopName = "§e§lITEM SHOP"
+	}
+	if c.NPCs.UpgradesName == "" {
+		c.NPCs.UpgradesName = "§b§lUPGRADES"
+	}
+	if c.Combat.TagWindow <= 0 {
+		c.Combat.TagWindow = 10
+	}
+}
+
+func (p *PhaseConfig) applyDefaults() {
+	if p.Countdown <= 0 {
+		p.Countdown = 10
+	}
+	if p.Duration <= 0 {
+		p.Duration = 1800
+	}
+	if p.SuddenDeath <= 0 {
+		p.SuddenDeath = 300
+	}
+	if p.Ending <= 0 {
+		p.Ending = 10
+	}
+	if p.Respawn <= 0 {
+		p.Respawn = 3
+	}
+	if p.Rejoin == 0 {
+		p.Rejoin = 60
+	}
 }
 
 func createDefaultConfig() *Config {
-        return &Config{
-                Arenas: map[string]*ArenaConfig{
-                        "default": {
-                                World:      "world",
-                                MinPlayers: 2,
-                                MaxPlayers: 8,
-                                LobbySpawn: mgl64.Vec3{0, 100, 0},
-                                Teams: map[string]*TeamConfig{
-                                        "red": {
-                                                Spawn:     mgl64.Vec3{50, 100, 0},
-                                                Egg:       cube.Pos{45, 101, 0},
-                                                Generator: mgl64.Vec3{48, 100, 0},
-                                        },
-                                        "blue": {
-                                                Spawn:     mgl64.Vec3{-50, 100, 0},
-                                                Egg:       cube.Pos{-45, 101, 0},
-                                                Generator: mgl64.Vec3{-48, 100, 0},
-                                        },
-                                        "green": {
-                                                Spawn:     mgl64.Vec3{0, 100, 50},
-                                                Egg:       cube.Pos{0, 101, 45},
-                                                Generator: mgl64.Vec3{0, 100, 48},
-                                        },
-                                        "yellow": {
-                                                Spawn:     mgl64.Vec3{0, 100, -50},
-                                                Egg:       cube.Pos{0, 101, -45},
-                                                Generator: mgl64.Vec3{0, 100, -48},
-                                        },
-                                },
-                        },
-                        "islands": {
-                                World:      "world",
-                                MinPlayers: 2,
-                                MaxPlayers: 8,
-                                LobbySpawn: mgl64.Vec3{0, 150, 0},
-                                Teams: map[string]*TeamConfig{
-                                        "red": {
-                                                Spawn:     mgl64.Vec3{100, 150, 100},
-                                                Egg:       cube.Pos{95, 151, 100},
-                                                Generator: mgl64.Vec3{98, 150, 100},
-                                        },
-                                        "blue": {
-                                                Spawn:     mgl64.Vec3{-100, 150, -100},
-                                                Egg:       cube.Pos{-95, 151, -100},
-                                                Generator: mgl64.Vec3{-98, 150, -100},
-                                        },
-                                        "green": {
-                                                Spawn:     mgl64.Vec3{100, 150, -100},
-                                                Egg:       cube.Pos{95, 151, -100},
-                                                Generator: mgl64.Vec3{98, 150, -100},
-                                        },
-                                        "yellow": {
-                                                Spawn:     mgl64.Vec3{-100, 150, 100},
-                                                Egg:       cube.Pos{-95, 151, 100},
-                                                Generator: mgl64.Vec3{-98, 150, 100},
-                                        },
-                                },
-                        },
-                },
-                Shop: &ShopConfig{
-                        Items: map[string]*ShopItem{
-                                "sword_wood": {
-                                        Name:     "Wooden Sword",
-                                        Price:    10,
-                                        Currency: "iron",
-                                        Item:     "minecraft:wooden_sword",
-                                        Amount:   1,
-                                },
-                                "blocks": {
-                                        Name:     "Blocks (16x)",
-                                        Price:    5,
-                                        Currency: "iron",
-                                        Item:     "minecraft:wool",
-                                        Amount:   16,
-                                },
-                        },
-                },
-        }
+	return &Config{
+		MapsDir:      "maps",
+		InstancesDir: "instances",
+		MatchesDir:   "matches",
+		Rewards:      RewardsConfig{Game: 5, Kill: 2, Egg: 5, Win: 20},
+		Arenas: map[string]*ArenaConfig{
+			"default": {
+				World:          "world",
+				MinPlayers:     2,
+				TeamSize:       2,
+				LobbySpawn:     mgl64.Vec3{0, 100, 0},
+				SpectatorSpawn: mgl64.Vec3{0, 120, 0},
+				Teams: map[string]*TeamConfig{
+					"red": {
+						Spawn:    mgl64.Vec3{50, 100, 0},
+						Egg:      cube.Pos{45, 101, 0},
+						Shop:     &NPCConfig{Pos: mgl64.Vec3{50, 100, 3}, Yaw: 180},
+						Upgrades: &NPCConfig{Pos: mgl64.Vec3{50, 100, -3}},
+					},
+					"blue": {
+						Spawn:    mgl64.Vec3{-50, 100, 0},
+						Egg:      cube.Pos{-45, 101, 0},
+						Shop:     &NPCConfig{Pos: mgl64.Vec3{-50, 100, 3}, Yaw: 180},
+						Upgrades: &NPCConfig{Pos: mgl64.Vec3{-50, 100, -3}},
+					},
+					"green": {
+						Spawn:    mgl64.Vec3{0, 100, 50},
+						Egg:      cube.Pos{0, 101, 45},
+						Shop:     &NPCConfig{Pos: mgl64.Vec3{0, 100, 53}, Yaw: 180},
+						Upgrades: &NPCConfig{Pos: mgl64.Vec3{0, 100, 47}},
+					},
+					"yellow": {
+						Spawn:    mgl64.Vec3{0, 100, -50},
+						Egg:      cube.Pos{0, 101, -45},
+						Shop:     &NPCConfig{Pos: mgl64.Vec3{0, 100, -47}, Yaw: 180},
+						Upgrades: &NPCConfig{Pos: mgl64.Vec3{0, 100, -53}},
+					},
+				},
+				Bounds:      [2]cube.Pos{{-64, 60, -64}, {64, 160, 64}},
+				VoidY:       60,
+				BuildHeight: 130,
+				Protection:  ProtectionConfig{EggRadius: 2, SpawnRadius: 3},
+				Generators: []*GeneratorConfig{
+					{Type: "iron", Team: "red", Position: cube.Pos{48, 99, 0}, Level: 1},
+					{Type: "gold", Team: "red", Position: cube.Pos{48, 99, 2}, Level: 0},
+					{Type: "iron", Team: "blue", Position: cube.Pos{-48, 99, 0}, Level: 1},
+					{Type: "gold", Team: "blue", Position: cube.Pos{-48, 99, -2}, Level: 0},
+					{Type: "iron", Team: "green", Position: cube.Pos{0, 99, 48}, Level: 1},
+					{Type: "gold", Team: "green", Position: cube.Pos{-2, 99, 48}, Level: 0},
+					{Type: "iron", Team: "yellow", Position: cube.Pos{0, 99, -48}, Level: 1},
+					{Type: "gold", Team: "yellow", Position: cube.Pos{2, 99, -48}, Level: 0},
+					{Type: "diamond", Position: cube.Pos{6, 99, 6}, Level: 1},
+					{Type: "diamond", Position: cube.Pos{-6, 99, -6}, Level: 1},
+				},
+			},
+			"islands": {
+				World:          "world",
+				MinPlayers:     2,
+				TeamSize:       1,
+				LobbySpawn:     mgl64.Vec3{0, 150, 0},
+				SpectatorSpawn: mgl64.Vec3{0, 170, 0},
+				Teams: map[string]*TeamConfig{
+					"red": {
+						Spawn:    mgl64.Vec3{100, 150, 100},
+						Egg:      cube.Pos{95, 151, 100},
+						Shop:     &NPCConfig{Pos: mgl64.Vec3{100, 150, 103}, Yaw: 180},
+						Upgrades: &NPCConfig{Pos: mgl64.Vec3{100, 150, 97}},
+					},
+					"blue": {
+						Spawn:    mgl64.Vec3{-100, 150, -100},
+						Egg:      cube.Pos{-95, 151, -100},
+						Shop:     &NPCConfig{Pos: mgl64.Vec3{-100, 150, -97}, Yaw: 180},
+						Upgrades: &NPCConfig{Pos: mgl64.Vec3{-100, 150, -103}},
+					},
+					"green": {
+						Spawn:    mgl64.Vec3{100, 150, -100},
+						Egg:      cube.Pos{95, 151, -100},
+						Shop:     &NPCConfig{Pos: mgl64.Vec3{100, 150, -97}, Yaw: 180},
+						Upgrades: &NPCConfig{Pos: mgl64.Vec3{100, 150, -103}},
+					},
+					"yellow": {
+						Spawn:    mgl64.Vec3{-100, 150, 100},
+						Egg:      cube.Pos{-95, 151, 100},
+						Shop:     &NPCConfig{Pos: mgl64.Vec3{-100, 150, 103}, Yaw: 180},
+						Upgrades: &NPCConfig{Pos: mgl64.Vec3{-100, 150, 97}},
+					},
+				},
+				Bounds:      [2]cube.Pos{{-128, 110, -128}, {128, 210, 128}},
+				VoidY:       110,
+				BuildHeight: 180,
+				Protection:  ProtectionConfig{EggRadius: 2, SpawnRadius: 3},
+				Generators: []*GeneratorConfig{
+					{Type: "iron", Team: "red", Position: cube.Pos{98, 149, 100}, Level: 1},
+					{Type: "gold", Team: "red", Position: cube.Pos{98, 149, 98}, Level: 0},
+					{Type: "iron", Team: "blue", Position: cube.Pos{-98, 149, -100}, Level: 1},
+					{Type: "gold", Team: "blue", Position: cube.Pos{-98, 149, -98}, Level: 0},
+					{Type: "iron", Team: "green", Position: cube.Pos{98, 149, -100}, Level: 1},
+					{Type: "gold", Team: "green", Position: cube.Pos{98, 149, -98}, Level: 0},
+					{Type: "iron", Team: "yellow", Position: cube.Pos{-98, 149, 100}, Level: 1},
+					{Type: "gold", Team: "yellow", Position: cube.Pos{-98, 149, 98}, Level: 0},
+					{Type: "diamond", Position: cube.Pos{5, 149, 5}, Level: 1},
+					{Type: "diamond", Position: cube.Pos{-5, 149, -5}, Level: 1},
+				},
+			},
+		},
+		Shop: &ShopConfig{
+			Items: map[string]*ShopItem{
+				"wool": {
+					Name: "Wool (16x)", Category: "blocks",
+					Price: 4, Currency: "iron", Item: "minecraft:white_wool", Amount: 16,
+				},
+				"end_stone": {
+					Name: "End Stone (8x)", Category: "blocks",
+					Price: 12, Currency: "iron", Item: "minecraft:end_stone", Amount: 8,
+				},
+				"obsidian": {
+					Name: "Obsidian", Category: "blocks",
+					Price: 4, Currency: "diamond", Item: "minecraft:obsidian", Amount: 1,
+				},
+				"sword_stone": {
+					Name: "Stone Sword", Category: "weapons",
+					Price: 10, Currency: "iron", Item: "minecraft:stone_sword", Amount: 1,
+				},
+				"sword_iron": {
+					Name: "Iron Sword", Category: "weapons",
+					Price: 7, Currency: "gold", Item: "minecraft:iron_sword", Amount: 1,
+				},
+				"sword_knockback": {
+					Name: "Knockback Stick", Category: "weapons",
+					Price: 5, Currency: "gold", Item: "minecraft:stick", Amount: 1,
+					Enchantments: map[string]int{"knockback": 1}, CustomName: "§dKnockback Stick",
+				},
+				"bow": {
+					Name: "Bow", Category: "weapons",
+					Price: 12, Currency: "gold", Item: "minecraft:bow", Amount: 1,
+				},
+				"arrows": {
+					Name: "Arrows (8x)", Category: "weapons",
+					Price: 2, Currency: "gold", Item: "minecraft:arrow", Amount: 8,
+				},
+				"chainmail_chestplate": {
+					Name: "Chainmail Chestplate", Category: "armour",
+					Price: 24, Currency: "iron", Item: "minecraft:chainmail_chestplate", Amount: 1,
+					Permanent: true,
+				},
+				"iron_chestplate": {
+					Name: "Iron Chestplate", Category: "armour",
+					Price: 12, Currency: "gold", Item: "minecraft:iron_chestplate", Amount: 1,
+					Permanent: true,
+				},
+				"diamond_chestplate": {
+					Name: "Diamond Chestplate", Category: "armour",
+					Price: 6, Currency: "diamond", Item: "minecraft:diamond_chestplate", Amount: 1,
+					Permanent: true,
+				},
+				"pickaxe_iron": {
+					Name: "Iron Pickaxe", Category: "tools",
+					Price: 10, Currency: "iron", Item: "minecraft:iron_pickaxe", Amount: 1,
+					Enchantments: map[string]int{"efficiency": 1},
+				},
+				"shears": {
+					Name: "Shears", Category: "tools",
+					Price: 20, Currency: "iron", Item: "minecraft:shears", Amount: 1,
+				},
+				"potion_speed": {
+					Name: "Speed II Potion", Category: "potions",
+					Price: 1, Currency: "diamond", Item: "minecraft:potion", Amount: 1, Potion: 16,
+				},
+				"potion_healing": {
+					Name: "Healing Potion", Category: "potions",
+					Price: 3, Currency: "gold", Item: "minecraft:potion", Amount: 1, Potion: 21,
+				},
+				"golden_apple": {
+					Name: "Golden Apple", Category: "utility",
+					Price: 3, Currency: "gold", Item: "minecraft:golden_apple", Amount: 1,
+				},
+				"tnt": {
+					Name: "TNT", Category: "utility",
+					Price: 4, Currency: "gold", Item: "minecraft:tnt", Amount: 1,
+				},
+				"ender_pearl": {
+					Name: "Ender Pearl", Category: "utility",
+					Price: 4, Currency: "diamond", Item: "minecraft:ender_pearl", Amount: 1,
+				},
+				"bridge_egg": {
+					Name: "Bridge Egg", Category: "utility",
+					Price: 2, Currency: "diamond", Item: "eggwars:bridge_egg", Amount: 1,
+				},
+				"instant_tnt": {
+					Name: "Instant TNT", Category: "utility",
+					Price: 6, Currency: "gold", Item: "eggwars:instant_tnt", Amount: 1,
+				},
+				"fireball": {
+					Name: "Fireball", Category: "utility",
+					Price: 40, Currency: "iron", Item: "eggwars:throwable_fireball", Amount: 1,
+				},
+				"rescue_platform": {
+					Name: "Rescue Platform", Category: "utility",
+					Price: 1, Currency: "diamond", Item: "eggwars:rescue_platform", Amount: 1,
+				},
+				"tracker": {
+					Name: "Enemy Tracker", Category: "utility",
+					Price: 10, Currency: "gold", Item: "eggwars:enemy_tracker", Amount: 1,
+				},
+			},
+		},
+		Kits: map[string]*KitConfig{
+			"starter": {
+				Name: "Starter", Description: "A wooden sword and pickaxe.",
+				Icon: "textures/items/wood_sword", Default: true,
+				Items: []*KitItem{
+					{Item: "minecraft:wooden_sword"},
+					{Item: "minecraft:wooden_pickaxe"},
+				},
+			},
+			"builder": {
+				Name: "Builder", Description: "Wool and an efficient pickaxe to build fast.",
+				Icon: "textures/blocks/wool_colored_white", Cost: 50,
+				Items: []*KitItem{
+					{Item: "minecraft:wooden_sword"},
+					{Item: "minecraft:stone_pickaxe", Enchantments: map[string]int{"efficiency": 2}},
+					{Item: "minecraft:white_wool", Amount: 16},
+				},
+			},
+			"scout": {
+				Name: "Scout", Description: "Chainmail boots and lasting speed.",
+				Icon: "textures/items/chainmail_boots", Cost: 100,
+				Boots: &KitItem{Item: "minecraft:chainmail_boots"},
+				Items: []*KitItem{
+					{Item: "minecraft:wooden_sword"},
+				},
+				Effects: []*KitEffect{{Type: "speed", Level: 1}},
+			},
+		},
+	}
 }
 
 func saveConfig(cfg *Config, path string, log *logrus.Logger) {
-        data, err := toml.Marshal(cfg)
-        if err != nil {
-                log.Fatalf("Failed to marshal config: %v", err)
-        }
-        
-        if err := os.WriteFile(path, data, 0644); err != nil {
-                log.Fatalf("Failed to write config: %v", err)
-        }
-        
-        log.Infof("Created default config at %s", path)
+	if _, err := Save(cfg, path); err != nil {
+		log.Fatalf("Failed to save config: %v", err)
+	}
+	log.Infof("Created default config at %s", path)
+}
+
+// Save writes cfg to the file at path, replacing its current contents and any
+// comments in it. Missing values are filled in with their defaults in a copy
+// of cfg, which is returned, so cfg itself is left untouched and may be in use
+// elsewhere.
+func Save(cfg *Config, path string) (*Config, error) {
+	c, err := cfg.Clone()
+	if err != nil {
+		return nil, err
+	}
+	c.applyDefaults()
+	data, err := toml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+
+	// Write to a temporary file first, so that a crash halfway through does
+	// not leave a truncated config behind.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return nil, fmt.Errorf("write config: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Clone returns a deep copy of c, which may be changed without affecting c.
+func (c *Config) Clone() (*Config, error) {
+	data, err := toml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	var clone Config
+	if err := toml.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	return &clone, nil
 }
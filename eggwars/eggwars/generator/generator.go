@@ -1,8 +1,16 @@
 package generator
 
 import (
+	"fmt"
+	"math"
+	"sync"
 	"time"
 
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/shop"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/entity"
 	"github.com/df-mc/dragonfly/server/item"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/go-gl/mathgl/mgl64"
@@ -16,82 +24,286 @@ const (
 	Diamond ResourceType = "diamond"
 )
 
+func (r ResourceType) Title() string {
+	switch r {
+	case Gold:
+		return "§6Gold"
+	case Diamond:
+		return "§bDiamond"
+	default:
+		return "§7Iron"
+	}
+}
+
+func (r ResourceType) Item() world.Item {
+	switch r {
+	case Gold:
+		return item.GoldIngot{}
+	case Diamond:
+		return item.Diamond{}
+	default:
+		return item.IronIngot{}
+	}
+}
+
+// Level is a single tier of a generator.
+type Level struct {
+	// Interval is the time between two drops.
+	Interval time.Duration
+	// Amount is the amount of resources dropped at once.
+	Amount int
+	// Cost is the price of upgrading the generator to this level, paid in
+	// Currency.
+	Cost     int
+	Currency string
+}
+
+// minInterval is the shortest drop interval a generator level may have.
+const minInterval = time.Second / 4
+
 type Generator struct {
-	Position     mgl64.Vec3
+	// Pos is the position of the generator's block. Resources are dropped on
+	// top of it.
+	Pos          cube.Pos
 	ResourceType ResourceType
-	Interval     time.Duration
-	World        *world.World
-	running      bool
-	stopChan     chan bool
+	// Team is the name of the team owning the generator. It is empty for
+	// generators shared by all teams.
+	Team   string
+	Levels []Level
+	// Cap is the maximum amount of resources that may lie on the ground
+	// around the generator. No more resources are spawned while it is reached.
+	Cap int
+
+	mu    sync.Mutex
+	level int
+	// boost is how much faster than its level the generator drops
+	// resources, such as 0.5 for 50% faster.
+	boost    float64
+	next     time.Time
+	hologram *world.EntityHandle
 }
 
-func NewGenerator(pos mgl64.Vec3, resType ResourceType, w *world.World) *Generator {
-	interval := 2 * time.Second
+// New creates a Generator from its configuration. Levels and costs left
+// out of the configuration are filled in from the defaults of the resource
+// type. Every level above the starting one must have a price, so that the
+// generator cannot be upgraded for free.
+func New(cfg *config.GeneratorConfig) (*Generator, error) {
+	resType := ResourceType(cfg.Type)
+	levels, limit := defaults(resType)
+	if levels == nil {
+		return nil, fmt.Errorf("unknown generator type %q", cfg.Type)
+	}
+
+	if len(cfg.Intervals) > 0 {
+		def := levels
+		levels = make([]Level, len(cfg.Intervals))
+		for i, sec := range cfg.Intervals {
+			levels[i] = Level{Interval: time.Duration(sec * float64(time.Second)), Amount: 1}
+			if i < len(def) {
+				levels[i].Cost, levels[i].Currency = def[i].Cost, def[i].Currency
+			}
+		}
+	}
+	if cfg.Level < 0 || cfg.Level > len(levels) {
+		return nil, fmt.Errorf("starting level %d out of range 0-%d", cfg.Level, len(levels))
+	}
+	for i := range levels {
+		if i < len(cfg.Amounts) {
+			levels[i].Amount = cfg.Amounts[i]
+		}
+		if i < len(cfg.UpgradeCosts) {
+			levels[i].Cost = cfg.UpgradeCosts[i]
+		}
+		if cfg.UpgradeCurrency != "" {
+			levels[i].Currency = cfg.UpgradeCurrency
+		}
+		if levels[i].Interval < minInterval || levels[i].Amount < 1 {
+			return nil, fmt.Errorf("level %d: invalid interval %v or amount %d", i+1, levels[i].Interval, levels[i].Amount)
+		}
+		// The levels up to the starting one are never bought.
+		if i < cfg.Level {
+			continue
+		}
+		if levels[i].Cost < 1 {
+			return nil, fmt.Errorf("level %d: no upgrade cost", i+1)
+		}
+		if _, ok := shop.CurrencyItem(levels[i].Currency); !ok {
+			return nil, fmt.Errorf("level %d: unknown upgrade currency %q", i+1, levels[i].Currency)
+		}
+	}
+	if cfg.Cap > 0 {
+		limit = cfg.Cap
+	}
 
+	return &Generator{
+		Pos:          cfg.Position,
+		ResourceType: resType,
+		Team:         cfg.Team,
+		Levels:       levels,
+		Cap:          limit,
+		level:        cfg.Level,
+	}, nil
+}
+
+// defaults returns the default levels and cap of a resource type. Upgrades
+// get more expensive with every level and are paid in iron first, which every
+// team earns from the start, then in gold and finally in diamonds. Iron
+// generators have no price for their first level, as they start active.
+func defaults(resType ResourceType) ([]Level, int) {
 	switch resType {
+	case Iron:
+		return []Level{
+			{Interval: 2 * time.Second, Amount: 1},
+			{Interval: 1500 * time.Millisecond, Amount: 1, Cost: 24, Currency: "iron"},
+			{Interval: time.Second, Amount: 1, Cost: 12, Currency: "gold"},
+			{Interval: time.Second, Amount: 2, Cost: 6, Currency: "diamond"},
+		}, 48
 	case Gold:
-		interval = 5 * time.Second
+		return []Level{
+			{Interval: 6 * time.Second, Amount: 1, Cost: 32, Currency: "iron"},
+			{Interval: 4 * time.Second, Amount: 1, Cost: 16, Currency: "gold"},
+			{Interval: 2 * time.Second, Amount: 1, Cost: 8, Currency: "diamond"},
+		}, 16
 	case Diamond:
-		interval = 10 * time.Second
+		return []Level{
+			{Interval: 20 * time.Second, Amount: 1, Cost: 48, Currency: "iron"},
+			{Interval: 12 * time.Second, Amount: 1, Cost: 24, Currency: "gold"},
+			{Interval: 8 * time.Second, Amount: 1, Cost: 12, Currency: "diamond"},
+		}, 4
 	}
+	return nil, 0
+}
 
-	return &Generator{
-		Position:     pos,
-		ResourceType: resType,
-		Interval:     interval,
-		World:        w,
-		stopChan:     make(chan bool),
+// Level returns the current level of the generator. A generator at level 0 is
+// inactive until it is upgraded.
+func (g *Generator) Level() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.level
+}
+
+// NextLevel returns the level the generator would be upgraded to next. It
+// returns false if the generator is already at its highest level.
+func (g *Generator) NextLevel() (Level, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.level >= len(g.Levels) {
+		return Level{}, false
 	}
+	return g.Levels[g.level], true
 }
 
-func (g *Generator) Start() {
-	if g.running {
-		return
+// TryUpgrade raises the level of the generator by one if it is still at the
+// level passed, starting the interval of the new level at now. It returns false
+// if the generator was upgraded meanwhile or is already at its highest level.
+func (g *Generator) TryUpgrade(level int, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.level != level || g.level >= len(g.Levels) {
+		return false
 	}
+	g.level++
+	g.next = now.Add(g.interval())
+	return true
+}
 
-	g.running = true
-	go g.generate()
+// Boost makes the generator drop resources faster than its level does, such
+// as 0.5 for 50% faster. A boost of 0 removes the boost.
+func (g *Generator) Boost(boost float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.boost = max(boost, 0)
 }
 
-func (g *Generator) Stop() {
-	if !g.running {
-		return
+// interval returns the time between two drops of the generator at its current
+// level and boost. g.mu must be held and the generator must be active.
+func (g *Generator) interval() time.Duration {
+	return time.Duration(float64(g.Levels[g.level-1].Interval) / (1 + g.boost))
+}
+
+// Tick drops resources if the generator's interval has passed and updates the
+// countdown on its hologram. Tick must be called with a transaction of the
+// generator's world, a few times per second for as long as it runs.
+func (g *Generator) Tick(tx *world.Tx, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.level > 0 {
+		lvl := g.Levels[g.level-1]
+		if g.next.IsZero() {
+			g.next = now.Add(g.interval())
+		} else if !now.Before(g.next) {
+			g.drop(tx, lvl.Amount)
+			g.next = now.Add(g.interval())
+		}
 	}
+	g.updateHologram(tx, now)
+}
 
-	g.running = false
-	g.stopChan <- true
+func (g *Generator) drop(tx *world.Tx, amount int) {
+	s := item.NewStack(g.ResourceType.Item(), 1)
+	if g.Cap > 0 {
+		amount = min(amount, g.Cap-g.groundCount(tx, s))
+	}
+	if amount <= 0 {
+		return
+	}
+	opts := world.EntitySpawnOpts{Position: g.dropPosition()}
+	tx.AddEntity(tx.World().EntityRegistry().Config().Item(opts, s.Grow(amount-1)))
 }
 
-func (g *Generator) generate() {
-	ticker := time.NewTicker(g.Interval)
-	defer ticker.Stop()
+// groundCount returns the amount of items comparable to s that are lying on
+// the ground around the generator.
+func (g *Generator) groundCount(tx *world.Tx, s item.Stack) int {
+	box := cube.Box(-2, -1, -2, 2, 2, 2).Translate(g.dropPosition())
 
-	for {
-		select {
-		case <-ticker.C:
-			g.spawnResource()
-		case <-g.stopChan:
-			return
+	n := 0
+	for e := range tx.EntitiesWithin(box) {
+		ent, ok := e.(*entity.Ent)
+		if !ok {
+			continue
+		}
+		if b, ok := ent.Behaviour().(*entity.ItemBehaviour); ok && b.Item().Comparable(s) {
+			n += b.Item().Count()
 		}
 	}
+	return n
 }
 
-func (g *Generator) spawnResource() {
-	var itemStack item.Stack
+func (g *Generator) dropPosition() mgl64.Vec3 {
+	return g.Pos.Vec3Middle().Add(mgl64.Vec3{0, 1})
+}
 
-	switch g.ResourceType {
-	case Iron:
-		itemStack = item.NewStack(item.IronIngot{}, 1)
-	case Gold:
-		itemStack = item.NewStack(item.GoldIngot{}, 1)
-	case Diamond:
-		itemStack = item.NewStack(item.Diamond{}, 1)
+func (g *Generator) updateHologram(tx *world.Tx, now time.Time) {
+	text := fmt.Sprintf("%s Generator\n§7Level §e%d", g.ResourceType.Title(), g.level)
+	if g.level == 0 {
+		text += "\n§cInactive §7- right-click to activate"
+	} else {
+		secs := int(math.Ceil(g.next.Sub(now).Seconds()))
+		text += fmt.Sprintf("\n§7Next drop in §f%ds", max(secs, 0))
 	}
 
-	if g.World != nil && itemStack.Count() > 0 {
-		e := g.World.EntityRegistry().Config().Item(world.EntitySpawnOpts{Position: g.Position}, itemStack)
-		<-g.World.Exec(func(tx *world.Tx) {
-			tx.AddEntity(e)
-		})
+	if g.hologram != nil {
+		if e, ok := g.hologram.Entity(tx); ok {
+			if e.(*entity.Ent).NameTag() != text {
+				e.(*entity.Ent).SetNameTag(text)
+			}
+			return
+		}
+	}
+	g.hologram = entity.NewText(text, g.Pos.Vec3Middle().Add(mgl64.Vec3{0, 2.5}))
+	tx.AddEntity(g.hologram)
+}
+
+// Close removes the generator's hologram.
+func (g *Generator) Close(tx *world.Tx) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.hologram == nil {
+		return
+	}
+	if e, ok := g.hologram.Entity(tx); ok {
+		_ = tx.RemoveEntity(e).Close()
 	}
+	g.hologram = nil
 }
@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+)
+
+func TestTryUpgrade(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		start     int
+		level     int
+		want      bool
+		wantLevel int
+	}{
+		{"upgrade from the level shown", 1, 1, true, 2},
+		{"upgraded by someone else first", 2, 1, false, 2},
+		{"already at the highest level", 3, 3, false, 3},
+		{"activate an inactive generator", 0, 0, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(&config.GeneratorConfig{Type: "gold", Level: tt.start})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if got := g.TryUpgrade(tt.level, now); got != tt.want {
+				t.Fatalf("TryUpgrade(%d): got %v, want %v", tt.level, got, tt.want)
+			}
+			if got := g.Level(); got != tt.wantLevel {
+				t.Errorf("level: got %d, want %d", got, tt.wantLevel)
+			}
+			if tt.want {
+				if want := now.Add(g.Levels[tt.wantLevel-1].Interval); !g.next.Equal(want) {
+					t.Errorf("next drop: got %v, want %v", g.next, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       config.GeneratorConfig
+		wantErr   bool
+		wantCosts []int
+		wantCurrs []string
+	}{
+		{
+			name:      "defaults rise in price and currency",
+			cfg:       config.GeneratorConfig{Type: "gold"},
+			wantCosts: []int{32, 16, 8},
+			wantCurrs: []string{"iron", "gold", "diamond"},
+		},
+		{
+			name:      "configured levels keep the default prices",
+			cfg:       config.GeneratorConfig{Type: "diamond", Level: 1, Intervals: []float64{30, 20, 10}, UpgradeCosts: []int{0, 20}},
+			wantCosts: []int{0, 20, 12},
+			wantCurrs: []string{"iron", "gold", "diamond"},
+		},
+		{
+			name:      "one currency for every upgrade",
+			cfg:       config.GeneratorConfig{Type: "iron", Level: 1, UpgradeCurrency: "emerald"},
+			wantCosts: []int{0, 24, 12, 6},
+			wantCurrs: []string{"emerald", "emerald", "emerald", "emerald"},
+		},
+		{
+			name:    "levels beyond the defaults without a price",
+			cfg:     config.GeneratorConfig{Type: "gold", Intervals: []float64{6, 4, 2, 1}, UpgradeCosts: []int{8, 16}},
+			wantErr: true,
+		},
+		{
+			name:    "free upgrade",
+			cfg:     config.GeneratorConfig{Type: "iron", Level: 1, UpgradeCosts: []int{0, 0}},
+			wantErr: true,
+		},
+		{
+			name:    "inactive iron generator without a price",
+			cfg:     config.GeneratorConfig{Type: "iron"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown currency",
+			cfg:     config.GeneratorConfig{Type: "gold", UpgradeCurrency: "coal"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(&tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("New: got levels %+v, want an error", g.Levels)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if len(g.Levels) != len(tt.wantCosts) {
+				t.Fatalf("levels: got %+v, want %d levels", g.Levels, len(tt.wantCosts))
+			}
+			for i, l := range g.Levels {
+				if l.Cost != tt.wantCosts[i] || l.Currency != tt.wantCurrs[i] {
+					t.Errorf("price of level %d: got %d %s, want %d %s", i+1, l.Cost, l.Currency, tt.wantCosts[i], tt.wantCurrs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/shop"
+
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/form"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// Buyer is a player upgrading a generator.
+type Buyer struct {
+	// Wallet is what the upgrade is paid with.
+	Wallet shop.Wallet
+	// Open is called before the upgrade is paid for. It returns the time the
+	// upgrade happens at, such as the time of the last tick of the match, and
+	// false if the upgrade is refused, such as once the match has ended.
+	Open func() (now time.Time, ok bool)
+	// Upgraded, if not nil, is called with the new level once the generator
+	// is upgraded.
+	Upgraded func(level int)
+}
+
+// OpenUpgrade sends p a form to upgrade g to its next level, bought by b.
+func (g *Generator) OpenUpgrade(p *player.Player, b Buyer) {
+	g.mu.Lock()
+	lvl := g.level
+	if lvl >= len(g.Levels) {
+		g.mu.Unlock()
+		p.Message(fmt.Sprintf("%s Generator §7is already at its highest level.", g.ResourceType.Title()))
+		return
+	}
+	next := g.Levels[lvl]
+	g.mu.Unlock()
+
+	body := fmt.Sprintf("§7Level: §e%d §7» §a%d\n", lvl, lvl+1)
+	body += fmt.Sprintf("§7Drops: §f%d every %.1fs\n", next.Amount, next.Interval.Seconds())
+	body += fmt.Sprintf("§7Cost: §f%d %s §7(you have %d)", next.Cost, next.Currency, b.Wallet.Balance(next.Currency))
+
+	p.SendForm(form.NewModal(upgradeModal{
+		g:        g,
+		b:        b,
+		level:    lvl,
+		cost:     next.Cost,
+		currency: next.Currency,
+		Upgrade:  form.NewButton("§aUpgrade", ""),
+		Cancel:   form.NewButton("Cancel", ""),
+	}, fmt.Sprintf("%s Generator", g.ResourceType.Title())).WithBody(body))
+}
+
+// upgradeModal is the ModalSubmittable confirming a generator upgrade. level,
+// cost and currency are the level the generator was at and the price shown
+// when the form was sent.
+type upgradeModal struct {
+	g        *Generator
+	b        Buyer
+	level    int
+	cost     int
+	currency string
+	Upgrade  form.Button
+	Cancel   form.Button
+}
+
+func (m upgradeModal) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok || pressed != m.Upgrade {
+		return
+	}
+	now, ok := m.b.Open()
+	if !ok {
+		p.Message("§c✗ Generators can no longer be upgraded.")
+		return
+	}
+
+	g, w := m.g, m.b.Wallet
+	if !w.Take(m.currency, m.cost) {
+		p.Message(fmt.Sprintf("§c✗ Not enough %s! Need: %d, Have: %d", m.currency, m.cost, w.Balance(m.currency)))
+		return
+	}
+	if !g.TryUpgrade(m.level, now) {
+		// Someone else upgraded the generator after the form was sent.
+		w.Give(m.currency, m.cost)
+		p.Message(fmt.Sprintf("§c✗ %s Generator §cwas already upgraded.", g.ResourceType.Title()))
+		return
+	}
+	lvl := m.level + 1
+	p.Message(fmt.Sprintf("§a✓ %s Generator §aupgraded to level %d!", g.ResourceType.Title(), lvl))
+	if m.b.Upgraded != nil {
+		m.b.Upgraded(lvl)
+	}
+}
@@ -1,66 +1,178 @@
 package eggwars
 
 import (
-        "github.com/df-mc/dragonfly/server/block/cube"
-        "github.com/df-mc/dragonfly/server/item"
-        "github.com/df-mc/dragonfly/server/player"
-        "github.com/df-mc/dragonfly/server/world"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/arena"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/kit"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
 )
 
 type PlayerHandler struct {
-        player.NopHandler
-        gm *GameManager
-        p  *player.Player
+	player.NopHandler
+	gm *GameManager
+	p  *player.Player
 }
 
 func NewPlayerHandler(gm *GameManager, p *player.Player) *PlayerHandler {
-        return &PlayerHandler{
-                gm: gm,
-                p:  p,
-        }
+	return &PlayerHandler{
+		gm: gm,
+		p:  p,
+	}
 }
 
 func (h *PlayerHandler) HandleQuit(p *player.Player) {
-        pd := h.gm.GetPlayerDataTyped(p.Name())
-        if pd != nil && pd.Arena != nil {
-                pd.Arena.RemovePlayer(p)
-        }
+	h.gm.RemovePlayer(p)
 }
 
 func (h *PlayerHandler) HandleDeath(p *player.Player, src world.DamageSource, keepInv *bool) {
-        pd := h.gm.GetPlayerDataTyped(p.Name())
-        if pd != nil && pd.Arena != nil {
-                pd.Arena.HandlePlayerDeath(p)
-                *keepInv = true
-        }
+	pd := h.gm.GetPlayerDataTyped(p.Name())
+	if pd != nil && pd.Arena != nil {
+		pd.Arena.HandlePlayerDeath(p, src)
+		*keepInv = true
+	}
+}
+
+func (h *PlayerHandler) HandleRespawn(p *player.Player, pos *mgl64.Vec3, w **world.World) {
+	pd := h.gm.GetPlayerDataTyped(p.Name())
+	if pd != nil && pd.Arena != nil && pd.Arena.HandleRespawn(p, pos, w) {
+		return
+	}
+	// Players that died in an arena they are no longer in, for example
+	// because the match ended meanwhile, respawn in the lobby.
+	if lobby := h.gm.server.World(); *w != lobby {
+		*w, *pos = lobby, lobby.Spawn().Vec3Middle()
+	}
+}
+
+func (h *PlayerHandler) HandleHurt(ctx *player.Context, damage *float64, immune bool, attackImmunity *time.Duration, src world.DamageSource) {
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil && !pd.Arena.HandleHurt(h.p, src) {
+		ctx.Cancel()
+	}
 }
 
+func (h *PlayerHandler) HandleAttackEntity(ctx *player.Context, e world.Entity, force, height *float64, critical *bool) {
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil && !pd.Arena.HandleAttack(h.p, e) {
+		ctx.Cancel()
+	}
+}
 
 func (h *PlayerHandler) HandleBlockBreak(ctx *player.Context, pos cube.Pos, drops *[]item.Stack, xp *int) {
-        pd := h.gm.GetPlayerDataTyped(h.p.Name())
-        if pd != nil && pd.Arena != nil {
-                if !pd.Arena.CanBreakBlock(h.p, pos) {
-                        ctx.Cancel()
-                }
-        }
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil {
+		if !pd.Arena.CanBreakBlock(h.p, pos) {
+			ctx.Cancel()
+			return
+		}
+		pd.Arena.RecordBlock(ctx.Val().Tx(), pos)
+	}
+}
+
+func (h *PlayerHandler) HandleMove(ctx *player.Context, newPos mgl64.Vec3, newRot cube.Rotation) {
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil && !pd.Arena.HandleMove(h.p, newPos) {
+		ctx.Cancel()
+	}
+}
+
+func (h *PlayerHandler) HandleStartBreak(ctx *player.Context, pos cube.Pos) {
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil && pd.Arena.InteractEgg(h.p, pos) {
+		ctx.Cancel()
+	}
 }
 
 func (h *PlayerHandler) HandleBlockPlace(ctx *player.Context, pos cube.Pos, b world.Block) {
-        pd := h.gm.GetPlayerDataTyped(h.p.Name())
-        if pd != nil && pd.Arena != nil {
-                if pd.Arena.IsPlaying() {
-                        pd.Arena.TrackPlacedBlock(pos)
-                }
-        }
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil {
+		if !pd.Arena.CanPlaceBlock(h.p, pos) {
+			ctx.Cancel()
+			return
+		}
+		if pd.Arena.IsPlaying() {
+			pd.Arena.RecordBlock(ctx.Val().Tx(), pos)
+			pd.Arena.TrackPlacedBlock(h.p, pos)
+		}
+	}
+}
+
+func (h *PlayerHandler) HandleItemPickup(ctx *player.Context, i *item.Stack) {
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil && !pd.IsAlive {
+		// Eliminated players must not take resources away from the
+		// players still in the game.
+		ctx.Cancel()
+	}
+}
+
+func (h *PlayerHandler) HandleItemUseOnBlock(ctx *player.Context, pos cube.Pos, face cube.Face, clickPos mgl64.Vec3) {
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil {
+		if pd.Arena.InteractEgg(h.p, pos) {
+			ctx.Cancel()
+			return
+		}
+		if g, ok := pd.Arena.GeneratorAt(pos); ok {
+			ctx.Cancel()
+			pd.Arena.OpenGeneratorUpgrade(h.p, g)
+			return
+		}
+		held, _ := h.p.HeldItems()
+		at := pos.Side(face)
+		if pd.Arena.UseUtility(h.p, held, &at) {
+			ctx.Cancel()
+			return
+		}
+		pd.Arena.RecordItemUse(ctx.Val().Tx(), held, pos, face)
+	}
 }
 
 func (h *PlayerHandler) HandleItemUse(ctx *player.Context) {
-        pd := h.gm.GetPlayerDataTyped(h.p.Name())
-        if pd != nil && pd.Arena != nil {
-                held, _ := h.p.HeldItems()
-                if _, ok := held.Item().(item.Paper); ok {
-                        ctx.Cancel()
-                        pd.Arena.OpenShop(h.p)
-                }
-        }
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil {
+		held, _ := h.p.HeldItems()
+		if pd.Spectator {
+			ctx.Cancel()
+			if _, ok := held.Item().(item.Compass); ok {
+				pd.Arena.OpenTeleporter(h.p)
+			} else if arena.IsSpectatorLeaveItem(held) {
+				h.gm.LeaveArena(h.p)
+			}
+			return
+		}
+		if arena.IsTeamSelectorItem(held) {
+			ctx.Cancel()
+			pd.Arena.OpenTeamSelector(h.p)
+			return
+		}
+		if arena.IsKitSelectorItem(held) {
+			ctx.Cancel()
+			pd.Arena.OpenKitSelector(h.p)
+			return
+		}
+		if pd.Arena.UseUtility(h.p, held, nil) {
+			ctx.Cancel()
+		}
+	}
+}
+
+func (h *PlayerHandler) HandleItemUseOnEntity(ctx *player.Context, e world.Entity) {
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil && pd.Arena.InteractNPC(h.p, e) {
+		ctx.Cancel()
+	}
+}
+
+func (h *PlayerHandler) HandleItemDrop(ctx *player.Context, s item.Stack) {
+	pd := h.gm.GetPlayerDataTyped(h.p.Name())
+	if pd != nil && pd.Arena != nil && (pd.Spectator || arena.IsTeamArmour(s) || kit.IsKitItem(s)) {
+		ctx.Cancel()
+	}
 }
@@ -0,0 +1,210 @@
+package kit
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/shop"
+
+	"github.com/df-mc/dragonfly/server/entity/effect"
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/sirupsen/logrus"
+)
+
+// kitKey is the key of the value that marks the items of kits.
+const kitKey = "eggwars:kit"
+
+// Kit is a set of items and effects that players get every time they spawn
+// in a match.
+type Kit struct {
+	ID          string
+	Name        string
+	Description string
+	Icon        string
+	// Cost is the amount of coins needed to unlock the kit. Kits that cost
+	// nothing may be used by everyone.
+	Cost int
+	// Armour holds the helmet, chestplate, leggings and boots of the kit.
+	// Slots without armour are empty stacks.
+	Armour  [4]item.Stack
+	Items   []item.Stack
+	Effects []effect.Effect
+}
+
+// Apply gives p the items and effects of the kit. Armour slots that are not
+// empty and items that do not fit in p's inventory are left alone.
+func (k *Kit) Apply(p *player.Player) {
+	a := p.Armour()
+	piece := func(current, s item.Stack) item.Stack {
+		if !current.Empty() || s.Empty() {
+			return current
+		}
+		return s
+	}
+	a.Set(
+		piece(a.Helmet(), k.Armour[0]),
+		piece(a.Chestplate(), k.Armour[1]),
+		piece(a.Leggings(), k.Armour[2]),
+		piece(a.Boots(), k.Armour[3]),
+	)
+
+	inv := p.Inventory()
+	for i, s := range k.Items {
+		if current, err := inv.Item(i); err == nil && current.Empty() {
+			_ = inv.SetItem(i, s)
+			continue
+		}
+		_, _ = inv.AddItem(s)
+	}
+	for _, e := range k.Effects {
+		p.AddEffect(e)
+	}
+}
+
+// IsKitItem checks if s was given to a player by a kit. Such items may not be
+// dropped.
+func IsKitItem(s item.Stack) bool {
+	_, ok := s.Value(kitKey)
+	return ok
+}
+
+// Kits holds all kits players may pick.
+type Kits struct {
+	kits map[string]*Kit
+	// sorted holds all kits from the cheapest to the most expensive.
+	sorted []*Kit
+	def    *Kit
+}
+
+// New builds the kits configured in cfg. Kits that cannot be resolved are
+// logged and left out.
+func New(cfg map[string]*config.KitConfig, log *logrus.Logger) *Kits {
+	ks := &Kits{kits: make(map[string]*Kit)}
+	for id, kc := range cfg {
+		k, err := parseKit(id, kc)
+		if err != nil {
+			log.Warnf("Skipping kit %s: %v", id, err)
+			continue
+		}
+		ks.kits[id] = k
+		ks.sorted = append(ks.sorted, k)
+		if kc.Default && (ks.def == nil || id < ks.def.ID) {
+			ks.def = k
+		}
+	}
+	sort.Slice(ks.sorted, func(i, j int) bool {
+		if ks.sorted[i].Cost != ks.sorted[j].Cost {
+			return ks.sorted[i].Cost < ks.sorted[j].Cost
+		}
+		return ks.sorted[i].ID < ks.sorted[j].ID
+	})
+	return ks
+}
+
+// All returns all kits, from the cheapest to the most expensive.
+func (ks *Kits) All() []*Kit {
+	return ks.sorted
+}
+
+// Kit returns the kit with the ID passed.
+func (ks *Kits) Kit(id string) (*Kit, bool) {
+	k, ok := ks.kits[id]
+	return k, ok
+}
+
+// Selected returns the kit used by a player that picked the kit with the ID
+// passed and unlocked the kits passed. The default kit is returned if the
+// player did not pick a kit or may no longer use it. It returns false if the
+// player has no kit at all.
+func (ks *Kits) Selected(id string, unlocked []string) (*Kit, bool) {
+	if k, ok := ks.kits[id]; ok && Unlocked(k, unlocked) {
+		return k, true
+	}
+	return ks.def, ks.def != nil
+}
+
+// Unlocked checks if a player that unlocked the kits passed may use k.
+func Unlocked(k *Kit, unlocked []string) bool {
+	return k.Cost <= 0 || slices.Contains(unlocked, k.ID)
+}
+
+func parseKit(id string, cfg *config.KitConfig) (*Kit, error) {
+	k := &Kit{ID: id, Name: cfg.Name, Description: cfg.Description, Icon: cfg.Icon, Cost: cfg.Cost}
+	if k.Name == "" {
+		k.Name = id
+	}
+	for i, ic := range []*config.KitItem{cfg.Helmet, cfg.Chestplate, cfg.Leggings, cfg.Boots} {
+		if ic == nil {
+			continue
+		}
+		s, err := parseItem(ic)
+		if err != nil {
+			return nil, err
+		}
+		k.Armour[i] = s
+	}
+	for _, ic := range cfg.Items {
+		s, err := parseItem(ic)
+		if err != nil {
+			return nil, err
+		}
+		k.Items = append(k.Items, s)
+	}
+	for _, ec := range cfg.Effects {
+		e, err := parseEffect(ec)
+		if err != nil {
+			return nil, err
+		}
+		k.Effects = append(k.Effects, e)
+	}
+	return k, nil
+}
+
+func parseItem(cfg *config.KitItem) (item.Stack, error) {
+	s, err := shop.NewStack(cfg.Item, cfg.Meta, cfg.Amount, cfg.Enchantments)
+	if err != nil {
+		return item.Stack{}, err
+	}
+	if cfg.CustomName != "" {
+		s = s.WithCustomName(cfg.CustomName)
+	}
+	return s.WithValue(kitKey, true), nil
+}
+
+func parseEffect(cfg *config.KitEffect) (effect.Effect, error) {
+	t, ok := effects[strings.ToLower(cfg.Type)]
+	if !ok {
+		return effect.Effect{}, fmt.Errorf("unknown effect %q", cfg.Type)
+	}
+	lvl := max(cfg.Level, 1)
+	if cfg.Duration > 0 {
+		return effect.New(t, lvl, time.Duration(cfg.Duration)*time.Second), nil
+	}
+	return effect.NewInfinite(t, lvl), nil
+}
+
+// effects maps the names of the lasting effects kits may give to their types.
+var effects = map[string]effect.LastingType{
+	"speed":           effect.Speed,
+	"slowness":        effect.Slowness,
+	"haste":           effect.Haste,
+	"mining_fatigue":  effect.MiningFatigue,
+	"strength":        effect.Strength,
+	"jump_boost":      effect.JumpBoost,
+	"regeneration":    effect.Regeneration,
+	"resistance":      effect.Resistance,
+	"fire_resistance": effect.FireResistance,
+	"water_breathing": effect.WaterBreathing,
+	"invisibility":    effect.Invisibility,
+	"night_vision":    effect.NightVision,
+	"weakness":        effect.Weakness,
+	"health_boost":    effect.HealthBoost,
+	"absorption":      effect.Absorption,
+	"slow_falling":    effect.SlowFalling,
+	"conduit_power":   effect.ConduitPower,
+}
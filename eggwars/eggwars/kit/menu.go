@@ -0,0 +1,68 @@
+package kit
+
+import (
+	"fmt"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/stats"
+
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/form"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// OpenSelector sends p a form listing all kits, from which it may pick a kit
+// or unlock one with its coins. id is the ID the stats of p are stored under
+// in sm, which also holds the kits p unlocked and picked.
+func (ks *Kits) OpenSelector(p *player.Player, sm *stats.StatsManager, id string) {
+	if len(ks.sorted) == 0 {
+		p.Message("§c✗ There are no kits to pick from.")
+		return
+	}
+	st := sm.GetStats(id, p.Name())
+	selected, _ := ks.Selected(st.Kit, st.Kits)
+
+	buttons := make([]form.Button, len(ks.sorted))
+	for i, k := range ks.sorted {
+		status := fmt.Sprintf("§c%d coins", k.Cost)
+		if k == selected {
+			status = "§aSelected"
+		} else if Unlocked(k, st.Kits) {
+			status = "§7Unlocked"
+		}
+		buttons[i] = form.NewButton(fmt.Sprintf("§l%s\n§r%s", k.Name, status), k.Icon)
+	}
+	p.SendForm(form.NewMenu(selectorMenu{ks: ks, sm: sm, id: id, buttons: buttons}, "§6Kits").
+		WithBody(fmt.Sprintf("§7Coins: §e%d\n\nYour kit is given to you every time you spawn.", st.Coins)).
+		WithButtons(buttons...))
+}
+
+// selectorMenu is the MenuSubmittable of the kit selector.
+type selectorMenu struct {
+	ks      *Kits
+	sm      *stats.StatsManager
+	id      string
+	buttons []form.Button
+}
+
+func (m selectorMenu) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok {
+		return
+	}
+	for i, b := range m.buttons {
+		if b != pressed {
+			continue
+		}
+		k := m.ks.sorted[i]
+		if st := m.sm.GetStats(m.id, p.Name()); !Unlocked(k, st.Kits) {
+			if !m.sm.UnlockKit(m.id, p.Name(), k.ID, k.Cost) {
+				p.Message(fmt.Sprintf("§c✗ You need %d coins to unlock %s, you have %d.", k.Cost, k.Name, st.Coins))
+				return
+			}
+			p.Message(fmt.Sprintf("§a✓ Unlocked the %s kit for %d coins!", k.Name, k.Cost))
+		}
+		m.sm.SelectKit(m.id, p.Name(), k.ID)
+		p.Message(fmt.Sprintf("§a✓ Selected the %s kit.", k.Name))
+		return
+	}
+}
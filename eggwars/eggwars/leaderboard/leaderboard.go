@@ -0,0 +1,151 @@
+package leaderboard
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/stats"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sirupsen/logrus"
+)
+
+// hologram is a leaderboard shown as floating text.
+type hologram struct {
+	stat   stats.Stat
+	window stats.Window
+	size   int
+	cfg    *config.HologramConfig
+	handle *world.EntityHandle
+}
+
+// Holograms shows the leaderboards configured as floating text in the lobby
+// and keeps them up to date.
+type Holograms struct {
+	w         *world.World
+	stats     *stats.StatsManager
+	log       *logrus.Logger
+	holograms []*hologram
+
+	closing chan struct{}
+	done    chan struct{}
+}
+
+// New creates the leaderboard holograms configured in cfg in the world w,
+// refreshing them from sm at the interval of cfg.
+func New(cfg config.LeaderboardsConfig, w *world.World, sm *stats.StatsManager, log *logrus.Logger) *Holograms {
+	h := &Holograms{
+		w:       w,
+		stats:   sm,
+		log:     log,
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for i, hc := range cfg.Holograms {
+		s, win := stats.Stat(hc.Stat), stats.Window(hc.Window)
+		if !slices.Contains(stats.Stats, s) {
+			log.Errorf("Skipping leaderboard %d: unknown stat %q", i+1, hc.Stat)
+			continue
+		}
+		if !slices.Contains(stats.Windows, win) {
+			log.Errorf("Skipping leaderboard %d: unknown window %q", i+1, hc.Window)
+			continue
+		}
+		h.holograms = append(h.holograms, &hologram{stat: s, window: win, size: hc.Size, cfg: hc})
+	}
+	go h.run(time.Duration(cfg.Refresh) * time.Second)
+	return h
+}
+
+func (h *Holograms) run(interval time.Duration) {
+	defer close(h.done)
+	if len(h.holograms) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	h.refresh(time.Now())
+	for {
+		select {
+		case now := <-ticker.C:
+			h.refresh(now)
+		case <-h.closing:
+			return
+		}
+	}
+}
+
+// refresh updates the text of all holograms with the leaderboards at the
+// time passed.
+func (h *Holograms) refresh(now time.Time) {
+	texts := make([]string, len(h.holograms))
+	for i, hg := range h.holograms {
+		entries, err := h.stats.Top(hg.stat, hg.window, now, hg.size)
+		if err != nil {
+			h.log.Errorf("Failed to load leaderboard of %s: %v", hg.stat, err)
+			return
+		}
+		texts[i] = Text(hg.stat, hg.window, entries)
+	}
+	<-h.w.Exec(func(tx *world.Tx) {
+		for i, hg := range h.holograms {
+			hg.update(tx, texts[i])
+		}
+	})
+}
+
+// update sets the text of the hologram, spawning it if it is not in the world
+// yet.
+func (hg *hologram) update(tx *world.Tx, text string) {
+	if hg.handle != nil {
+		if e, ok := hg.handle.Entity(tx); ok {
+			if e.(*entity.Ent).NameTag() != text {
+				e.(*entity.Ent).SetNameTag(text)
+			}
+			return
+		}
+	}
+	// Holograms are saved with the lobby, so the ones spawned before the
+	// server last shut down are removed before spawning a new one.
+	pos := hg.cfg.Pos
+	tx.Block(cube.PosFromVec3(pos))
+	var old []world.Entity
+	for e := range tx.EntitiesWithin(cube.Box(pos[0], pos[1], pos[2], pos[0], pos[1], pos[2]).Grow(0.5)) {
+		if e.H().Type() == entity.TextType {
+			old = append(old, e)
+		}
+	}
+	for _, e := range old {
+		_ = tx.RemoveEntity(e).Close()
+	}
+	hg.handle = entity.NewText(text, pos)
+	tx.AddEntity(hg.handle)
+}
+
+// Close stops refreshing the holograms. The holograms themselves are left in
+// the lobby, which is usually already closed when Close is called, and are
+// replaced when the holograms are created again.
+func (h *Holograms) Close() {
+	close(h.closing)
+	<-h.done
+}
+
+// Text returns a leaderboard of the players in entries, ranked by s within
+// the window w, as lines of text.
+func Text(s stats.Stat, w stats.Window, entries []stats.Entry) string {
+	lines := []string{fmt.Sprintf("§6§lTop %s §r§7(%s)", s.Title(), w.Title())}
+	if len(entries) == 0 {
+		lines = append(lines, "§8No players yet")
+	}
+	for i, e := range entries {
+		lines = append(lines, fmt.Sprintf("§e#%d §f%s §7- §a%s", i+1, e.Name, s.Format(e.Value)))
+	}
+	return strings.Join(lines, "\n")
+}
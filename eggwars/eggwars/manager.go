@@ -2,15 +2,28 @@ package eggwars
 
 import (
 	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/eggwars-dragonfly/eggwars/eggwars/arena"
 	"github.com/eggwars-dragonfly/eggwars/eggwars/commands"
 	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/kit"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/leaderboard"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/match"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/party"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/setup"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/shop"
 	"github.com/eggwars-dragonfly/eggwars/eggwars/stats"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
 
 	"github.com/df-mc/dragonfly/server"
 	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,21 +33,38 @@ type GameManager struct {
 	arenas  map[string]*arena.Arena
 	players map[string]*arena.PlayerData
 	stats   *stats.StatsManager
+	shop    *shop.Shop
+	kits    *kit.Kits
 	config  *config.Config
-	mu      sync.RWMutex
+	setup   *setup.Wizard
+	// leaderboards are the leaderboard holograms in the lobby.
+	leaderboards *leaderboard.Holograms
+	parties      *party.Manager
+	mu           sync.RWMutex
 }
 
 func NewGameManager(log *logrus.Logger, srv *server.Server) *GameManager {
 	cfg := config.LoadConfig(log)
 
+	store, err := stats.NewStore(cfg.Stats)
+	if err != nil {
+		log.Errorf("Failed to open stats store, stats will not be saved: %v", err)
+		store = stats.NewMemoryStore()
+	}
+
 	gm := &GameManager{
 		log:     log,
 		server:  srv,
 		arenas:  make(map[string]*arena.Arena),
 		players: make(map[string]*arena.PlayerData),
-		stats:   stats.NewStatsManager(log),
+		stats:   stats.NewStatsManager(store, cfg.Stats, log),
+		shop:    shop.New(cfg.Shop, log),
+		kits:    kit.New(cfg.Kits, log),
 		config:  cfg,
 	}
+	gm.setup = setup.New(cfg, gm, srv.World(), log)
+	gm.leaderboards = leaderboard.New(cfg.Leaderboards, srv.World(), gm.stats, log)
+	gm.parties = party.New(cfg.Party, gm.send)
 
 	commands.RegisterCommands(gm)
 
@@ -45,11 +75,25 @@ func (gm *GameManager) LoadArenas() {
 	gm.mu.Lock()
 	defer gm.mu.Unlock()
 
-	// Use the server's overworld for arenas.
-	w := gm.server.World()
+	// The server's overworld serves as the lobby. Matches themselves are
+	// played on copies of each arena's template map.
+	lobby := gm.server.World()
+
+	// Arena worlds are kept open between matches and are only removed when
+	// the server shuts down cleanly, so copies may be left over from a crash.
+	if err := os.RemoveAll(gm.config.InstancesDir); err != nil {
+		gm.log.Warnf("Could not remove old arena instances: %v", err)
+	}
 
 	for name, arenaCfg := range gm.config.Arenas {
-		a := arena.NewArena(name, arenaCfg, gm.log, w)
+		if err := arena.Validate(arenaCfg); err != nil {
+			gm.log.Errorf("Skipping arena %s: %v", name, err)
+			continue
+		}
+		if _, err := os.Stat(gm.config.TemplateDir(arenaCfg)); err != nil {
+			gm.log.Warnf("Template map of arena %s not found at %s", name, gm.config.TemplateDir(arenaCfg))
+		}
+		a := arena.NewArena(name, arenaCfg, gm.config, gm.log, lobby, gm.shop, gm.kits, gm.stats)
 		gm.arenas[name] = a
 		gm.log.Infof("Loaded arena: %s", name)
 	}
@@ -57,16 +101,149 @@ func (gm *GameManager) LoadArenas() {
 	gm.log.Infof("Loaded %d arenas", len(gm.arenas))
 }
 
+// Close closes the worlds of all arenas, removes their copies of the
+// template maps and saves all pending stats.
+func (gm *GameManager) Close() {
+	gm.setup.Close()
+	gm.leaderboards.Close()
+
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	for name, a := range gm.arenas {
+		if err := a.Close(); err != nil {
+			gm.log.Errorf("Failed to close arena %s: %v", name, err)
+		}
+	}
+	if err := gm.stats.Close(); err != nil {
+		gm.log.Errorf("Failed to close stats store: %v", err)
+	}
+}
+
+// ReloadArena (re)creates the arena with the name passed from the current
+// configuration. An arena that already exists is only replaced while nobody
+// is in it.
+func (gm *GameManager) ReloadArena(name string) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	cfg, ok := gm.config.Arenas[name]
+	if !ok {
+		return fmt.Errorf("arena %s does not exist", name)
+	}
+	if err := arena.Validate(cfg); err != nil {
+		return fmt.Errorf("arena %s is invalid: %w", name, err)
+	}
+	if old, ok := gm.arenas[name]; ok {
+		if state, count := old.Status(); state != arena.Waiting || count > 0 {
+			return fmt.Errorf("arena %s is in use", name)
+		}
+		if err := old.Close(); err != nil {
+			gm.log.Errorf("Failed to close arena %s: %v", name, err)
+		}
+	}
+	gm.arenas[name] = arena.NewArena(name, cfg, gm.config, gm.log, gm.server.World(), gm.shop, gm.kits, gm.stats)
+	gm.log.Infof("Loaded arena: %s", name)
+	return nil
+}
+
+// ArenaConfig returns the configuration of the arena with the name passed.
+func (gm *GameManager) ArenaConfig(name string) (*config.ArenaConfig, bool) {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	cfg, ok := gm.config.Arenas[name]
+	return cfg, ok
+}
+
+// SaveArena writes cfg to the configuration file as the arena with the name
+// passed. The configuration is written from a copy, and the arena is only
+// added to the configuration in use once it was written, so running arenas
+// never see a configuration that is being changed. cfg must not be changed
+// after calling SaveArena.
+func (gm *GameManager) SaveArena(name string, cfg *config.ArenaConfig) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	next, err := gm.config.Clone()
+	if err != nil {
+		return err
+	}
+	if next.Arenas == nil {
+		next.Arenas = make(map[string]*config.ArenaConfig)
+	}
+	next.Arenas[name] = cfg
+	saved, err := config.Save(next, config.Path)
+	if err != nil {
+		return err
+	}
+	if gm.config.Arenas == nil {
+		gm.config.Arenas = make(map[string]*config.ArenaConfig)
+	}
+	gm.config.Arenas[name] = saved.Arenas[name]
+	return nil
+}
+
+// IsAdmin checks if p may set up arenas. Admins are listed by name or XUID
+// in the configuration.
+func (gm *GameManager) IsAdmin(p *player.Player) bool {
+	return slices.Contains(gm.config.Admins, p.Name()) || (p.XUID() != "" && slices.Contains(gm.config.Admins, p.XUID()))
+}
+
+// Setup returns the wizard used to set up arenas in-game.
+func (gm *GameManager) Setup() *setup.Wizard {
+	return gm.setup
+}
+
+// Parties returns the parties of players that play together.
+func (gm *GameManager) Parties() *party.Manager {
+	return gm.parties
+}
+
+// send sends a message to the online player with the name passed. It returns
+// false if the player is not online. The message is sent from a new
+// goroutine, so send may be called from a transaction of any world.
+func (gm *GameManager) send(name, message string) bool {
+	pd := gm.GetPlayerDataTyped(name)
+	if pd == nil {
+		return false
+	}
+	h := pd.Player.H()
+	go h.ExecWorld(func(tx *world.Tx, e world.Entity) {
+		e.(*player.Player).Message(message)
+	})
+	return true
+}
+
+// RemovePlayer forgets p once it left the server, removing it from its
+// arena and party. Players that leave a running match are kept in it for a
+// while, so that they may come back through RejoinArena.
+func (gm *GameManager) RemovePlayer(p *player.Player) {
+	pd := gm.GetPlayerDataTyped(p.Name())
+	if pd != nil && pd.Arena != nil && !pd.Arena.Disconnect(p) {
+		pd.Arena.RemovePlayer(p)
+	}
+	gm.parties.Quit(p.Name())
+
+	gm.mu.Lock()
+	delete(gm.players, p.Name())
+	gm.mu.Unlock()
+}
+
 func (gm *GameManager) HandlePlayer(p *player.Player) {
 	gm.mu.Lock()
 	pd := &arena.PlayerData{
 		Player: p,
+		ID:     stats.PlayerID(p),
 	}
 	gm.players[p.Name()] = pd
 	gm.mu.Unlock()
 
 	handler := NewPlayerHandler(gm, p)
 	p.Handle(handler)
+
+	if a := gm.rejoinable(p, pd.ID); a != nil {
+		gm.offerRejoin(p, a)
+	}
 }
 
 func (gm *GameManager) GetArena(name string) interface{} {
@@ -112,14 +289,11 @@ func (gm *GameManager) GetPlayerDataTyped(name string) *arena.PlayerData {
 	return gm.players[name]
 }
 
+// JoinArena adds p to the arena with the name passed. If no arena has that
+// name but it is a mode, such as "doubles", p joins the arena of that mode
+// that is closest to starting. If p leads a party, its whole party joins with
+// it.
 func (gm *GameManager) JoinArena(p *player.Player, arenaName string) bool {
-	a := gm.GetArenaTyped(arenaName)
-	if a == nil {
-		p.Message(fmt.Sprintf("§c✗ Arena '%s' not found!", arenaName))
-		p.Message("§eUse /arenas to see available arenas.")
-		return false
-	}
-
 	pd := gm.GetPlayerDataTyped(p.Name())
 	if pd == nil {
 		p.Message("§c✗ Error: Player data not found! Please reconnect.")
@@ -131,26 +305,126 @@ func (gm *GameManager) JoinArena(p *player.Player, arenaName string) bool {
 		return false
 	}
 
-	if a.AddPlayer(p, pd) {
-		return true
+	var members []*arena.PlayerData
+	pd.Group = ""
+	if pa, ok := gm.parties.Of(p.Name()); ok {
+		if pa.Leader != p.Name() {
+			p.Message("§c✗ Only your party leader can join arenas for the party. Use /party leave to play alone.")
+			return false
+		}
+		pd.Group = pa.ID
+		for _, name := range pa.Members {
+			mpd := gm.GetPlayerDataTyped(name)
+			if name == p.Name() || mpd == nil {
+				continue
+			}
+			if mpd.Arena != nil {
+				p.Message(fmt.Sprintf("§e%s is in arena '%s' and will not join with the party.", name, mpd.Arena.Name))
+				continue
+			}
+			members = append(members, mpd)
+		}
+	}
+	size := len(members) + 1
+
+	a := gm.GetArenaTyped(arenaName)
+	if a == nil {
+		var known bool
+		if a, known = gm.arenaForMode(arenaName, size); a != nil {
+			arenaName = a.Name
+		} else if known {
+			p.Message(fmt.Sprintf("§c✗ All %s arenas are full or in game. Try again later!", arenaName))
+			return false
+		} else {
+			p.Message(fmt.Sprintf("§c✗ Arena '%s' not found!", arenaName))
+			p.Message("§eUse /arenas to see available arenas.")
+			return false
+		}
 	}
 
-	p.Message(fmt.Sprintf("§c✗ Could not join arena '%s'.", arenaName))
+	if size > 1 {
+		// The leader is told before anybody joins if the party does not fit
+		// in the arena or in a single team of it.
+		room, teamRoom := a.Room()
+		if room < size {
+			p.Message(fmt.Sprintf("§c✗ Your party of %d does not fit in arena '%s' (%d places left).", size, arenaName, room))
+			return false
+		}
+		if size > a.Config.TeamSize {
+			teams := (size + a.Config.TeamSize - 1) / a.Config.TeamSize
+			p.Message(fmt.Sprintf("§eYour party of %d is larger than the teams of %d in arena '%s' and will be split across %d teams.", size, a.Config.TeamSize, arenaName, teams))
+		} else if teamRoom < size {
+			p.Message(fmt.Sprintf("§eNo team in arena '%s' has room for your whole party, so it may be split.", arenaName))
+		}
+	}
 
-	switch a.State {
-	case arena.Playing:
+	if !a.AddPlayer(p, pd) {
+		gm.joinFailed(p, a)
+		return false
+	}
+	for _, mpd := range members {
+		mpd.Group = pd.Group
+		h := mpd.Player.H()
+		go h.ExecWorld(func(tx *world.Tx, e world.Entity) {
+			mp := e.(*player.Player)
+			if mpd.Arena != nil {
+				return
+			}
+			mp.Message(fmt.Sprintf("§dYour party leader took you to arena '%s'.", a.Name))
+			if !a.AddPlayer(mp, mpd) {
+				gm.joinFailed(mp, a)
+			}
+		})
+	}
+	return true
+}
+
+// joinFailed tells p why it could not join a.
+func (gm *GameManager) joinFailed(p *player.Player, a *arena.Arena) {
+	p.Message(fmt.Sprintf("§c✗ Could not join arena '%s'.", a.Name))
+
+	state, players := a.Status()
+	switch state {
+	case arena.Playing, arena.SuddenDeath:
 		p.Message("§eReason: Game is already in progress.")
-	case arena.Ending:
+		p.Message(fmt.Sprintf("§eUse /spectate %s to watch it.", a.Name))
+	case arena.Ending, arena.Resetting:
 		p.Message("§eReason: Game is ending.")
 	default:
-		if len(a.Players) >= a.Config.MaxPlayers {
-			p.Message(fmt.Sprintf("§eReason: Arena is full (%d/%d players).", len(a.Players), a.Config.MaxPlayers))
+		if players >= a.Config.MaxPlayers {
+			p.Message(fmt.Sprintf("§eReason: Arena is full (%d/%d players).", players, a.Config.MaxPlayers))
 		} else {
 			p.Message("§eReason: Unknown error.")
 		}
 	}
+}
 
-	return false
+// arenaForMode returns the arena of the mode passed that size players can join
+// together and that has the most players waiting. The second return value is
+// false if no arena has the mode at all.
+func (gm *GameManager) arenaForMode(mode string, size int) (*arena.Arena, bool) {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	var (
+		best      *arena.Arena
+		bestCount int
+		known     bool
+	)
+	for _, a := range gm.arenas {
+		if a.Config.Mode() != mode {
+			continue
+		}
+		known = true
+		state, players := a.Status()
+		if (state != arena.Waiting && state != arena.Starting) || players+size > a.Config.MaxPlayers {
+			continue
+		}
+		if best == nil || players > bestCount || (players == bestCount && a.Name < best.Name) {
+			best, bestCount = a, players
+		}
+	}
+	return best, known
 }
 
 func (gm *GameManager) LeaveArena(p *player.Player) bool {
@@ -160,17 +434,38 @@ func (gm *GameManager) LeaveArena(p *player.Player) bool {
 		return false
 	}
 
-	arenaName := pd.Arena.Name
-	pd.Arena.RemovePlayer(p)
+	a := pd.Arena
+	a.RemovePlayer(p)
+	a.SendToLobby(p)
+	p.Message(fmt.Sprintf("§a✓ You left arena '%s'.", a.Name))
+	return true
+}
 
-	gm.mu.Lock()
-	pd.Arena = nil
-	pd.Team = nil
-	pd.IsAlive = false
-	gm.mu.Unlock()
+func (gm *GameManager) SpectateArena(p *player.Player, arenaName string) bool {
+	a := gm.GetArenaTyped(arenaName)
+	if a == nil {
+		p.Message(fmt.Sprintf("§c✗ Arena '%s' not found!", arenaName))
+		p.Message("§eUse /arenas to see available arenas.")
+		return false
+	}
 
-	p.Message(fmt.Sprintf("§a✓ You left arena '%s'.", arenaName))
-	return true
+	pd := gm.GetPlayerDataTyped(p.Name())
+	if pd == nil {
+		p.Message("§c✗ Error: Player data not found! Please reconnect.")
+		return false
+	}
+
+	if pd.Arena != nil {
+		p.Message(fmt.Sprintf("§c✗ You are already in arena '%s'! Use /leave first.", pd.Arena.Name))
+		return false
+	}
+
+	if a.Spectate(p, pd) {
+		return true
+	}
+
+	p.Message(fmt.Sprintf("§c✗ No game is running in arena '%s'.", arenaName))
+	return false
 }
 
 func (gm *GameManager) ListArenas(p *player.Player) {
@@ -192,31 +487,42 @@ func (gm *GameManager) ListArenas(p *player.Player) {
 		return
 	}
 
+	// Arenas are grouped by mode, from the smallest teams to the largest.
+	sort.Slice(arenas, func(i, j int) bool {
+		if si, sj := arenas[i].Config.TeamSize, arenas[j].Config.TeamSize; si != sj {
+			return si < sj
+		}
+		return arenas[i].Name < arenas[j].Name
+	})
+
+	mode := ""
 	for _, a := range arenas {
-		stateMsg := ""
+		if m := a.Config.Mode(); m != mode {
+			mode = m
+			p.Message(fmt.Sprintf("§6» %s §7(/join %s)", strings.ToUpper(m[:1])+m[1:], m))
+		}
+
+		state, players := a.Status()
 		stateColor := "§7"
 
-		switch a.State {
+		switch state {
 		case arena.Waiting:
-			stateMsg = "Waiting"
 			stateColor = "§a"
 		case arena.Starting:
-			stateMsg = "Starting"
 			stateColor = "§e"
 		case arena.Playing:
-			stateMsg = "Playing"
 			stateColor = "§c"
-		case arena.Ending:
-			stateMsg = "Ending"
+		case arena.SuddenDeath:
+			stateColor = "§4"
+		case arena.Ending, arena.Resetting:
 			stateColor = "§8"
 		}
 
-		players := len(a.Players)
 		min := a.Config.MinPlayers
 		max := a.Config.MaxPlayers
 
-		p.Message(fmt.Sprintf("§f  %s §f%s%s", a.Name, stateColor, stateMsg))
-		p.Message(fmt.Sprintf("    §7Players: %d/%d (min: %d)", players, max, min))
+		p.Message(fmt.Sprintf("§f  %s §f%s%s", a.Name, stateColor, state))
+		p.Message(fmt.Sprintf("    §7Players: %d/%d (min: %d, %d teams of %d)", players, max, min, len(a.Config.Teams), a.Config.TeamSize))
 		p.Message(fmt.Sprintf("    §7Command: /join %s", a.Name))
 		p.Message("")
 	}
@@ -228,18 +534,116 @@ func (gm *GameManager) ListArenas(p *player.Player) {
 	}
 }
 
+// topListed is the number of players listed by ShowTop.
+const topListed = 10
+
+// ShowTop sends p the players ranked highest by the stat passed, within the
+// window passed.
+func (gm *GameManager) ShowTop(p *player.Player, stat, window string) {
+	s, w := stats.Stat(stat), stats.Window(window)
+	if !slices.Contains(stats.Stats, s) {
+		p.Message(fmt.Sprintf("§c✗ Unknown stat '%s'.", stat))
+		return
+	}
+	if !slices.Contains(stats.Windows, w) {
+		p.Message(fmt.Sprintf("§c✗ Unknown time frame '%s'.", window))
+		return
+	}
+	entries, err := gm.stats.Top(s, w, time.Now(), topListed)
+	if err != nil {
+		gm.log.Errorf("Failed to load leaderboard of %s: %v", s, err)
+		p.Message("§c✗ Error loading leaderboard.")
+		return
+	}
+	for _, line := range strings.Split(leaderboard.Text(s, w, entries), "\n") {
+		p.Message(line)
+	}
+}
+
+// matchesListed is the number of matches listed by ListMatches.
+const matchesListed = 10
+
+// ListMatches sends p the latest matches logged, with their winners.
+func (gm *GameManager) ListMatches(p *player.Player) {
+	ids, err := match.List(gm.config.MatchesDir)
+	if err != nil {
+		gm.log.Errorf("Failed to list matches: %v", err)
+		p.Message("§c✗ Error listing matches.")
+		return
+	}
+	if len(ids) == 0 {
+		p.Message("§eNo matches logged yet.")
+		return
+	}
+
+	p.Message(fmt.Sprintf("§6Latest matches §7(%d logged):", len(ids)))
+	for _, id := range ids[:min(len(ids), matchesListed)] {
+		l, err := match.Load(gm.config.MatchesDir, id)
+		if err != nil {
+			p.Message(fmt.Sprintf("§f  %s §c(unreadable)", id))
+			continue
+		}
+		s := l.Summary()
+		winner := "§8none"
+		if s.Winner != "" {
+			winner = teamTitle(s.Winner)
+		}
+		p.Message(fmt.Sprintf("§f  %s §7%s, %d players, winner: %s", id, s.Duration.Round(time.Second), len(s.Standings), winner))
+	}
+	p.Message("§7Use /ewadmin match <id> for details.")
+}
+
+// ShowMatch sends p the summary and standings of the match with the ID passed.
+func (gm *GameManager) ShowMatch(p *player.Player, id string) {
+	l, err := match.Load(gm.config.MatchesDir, id)
+	if os.IsNotExist(err) {
+		p.Message(fmt.Sprintf("§c✗ Match '%s' not found.", id))
+		return
+	} else if err != nil {
+		gm.log.Errorf("Failed to load match %s: %v", id, err)
+		p.Message(fmt.Sprintf("§c✗ Error loading match '%s'.", id))
+		return
+	}
+
+	s := l.Summary()
+	name := func(n string) string { return "§f" + n }
+	p.Message(fmt.Sprintf("§6Match %s §7in %s (%s), started %s", s.ID, s.Arena, s.Mode, s.Started.Format(time.DateTime)))
+	for _, line := range s.Lines(name, teamTitle) {
+		p.Message(line)
+	}
+	for _, line := range s.StandingLines(name) {
+		p.Message(line)
+	}
+}
+
+// teamTitle returns the title of the team with the colour passed.
+func teamTitle(colour string) string {
+	return team.Color(colour).Title()
+}
+
 func (gm *GameManager) ShowStats(p *player.Player) {
-	stats := gm.stats.GetStats(p.Name())
+	pd := gm.GetPlayerDataTyped(p.Name())
+	if pd == nil {
+		p.Message("§c✗ Error: Player data not found! Please reconnect.")
+		return
+	}
+	stats := gm.stats.GetStats(pd.ID, p.Name())
 
 	p.Message("§6╔═══════════════════════════════╗")
 	p.Message(fmt.Sprintf("§6║  Statistics for %s", p.Name()))
 	p.Message("§6╚═══════════════════════════════╝")
 	p.Message("")
 	p.Message(fmt.Sprintf("§f  Kills:   §e%d", stats.Kills))
+	p.Message(fmt.Sprintf("§f  Assists: §e%d", stats.Assists))
 	p.Message(fmt.Sprintf("§f  Deaths:  §e%d", stats.Deaths))
 	p.Message(fmt.Sprintf("§f  Wins:    §a%d", stats.Wins))
 	p.Message(fmt.Sprintf("§f  Losses:  §c%d", stats.Losses))
 	p.Message(fmt.Sprintf("§f  Games:   §8%d", stats.Games))
+	p.Message(fmt.Sprintf("§f  Eggs:    §6%d", stats.EggsDestroyed))
+	p.Message(fmt.Sprintf("§f  Blocks:  §7%d", stats.BlocksPlaced))
+	p.Message(fmt.Sprintf("§f  Played:  §7%s", stats.TimePlayed.Round(time.Minute)))
+	p.Message(fmt.Sprintf("§f  Rating:  §b%.0f", stats.Rating))
+	p.Message(fmt.Sprintf("§f  Coins:   §6%d", stats.Coins))
 	p.Message("")
 
 	kd := 0.0
@@ -0,0 +1,192 @@
+package match
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of thing that happened in a match.
+type EventType string
+
+const (
+	Join        EventType = "join"
+	Leave       EventType = "leave"
+	Start       EventType = "start"
+	TeamAssign  EventType = "team"
+	Kill        EventType = "kill"
+	EggDestroy  EventType = "egg"
+	Purchase    EventType = "purchase"
+	Upgrade     EventType = "upgrade"
+	Elimination EventType = "elimination"
+	End         EventType = "end"
+)
+
+// Event is a single line of a match log. Only the fields that apply to its
+// Type are set.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+	// Arena and Mode are set for Start events.
+	Arena string `json:"arena,omitempty"`
+	Mode  string `json:"mode,omitempty"`
+	// Player is the player the event is about: the killer of a Kill event or
+	// the player that broke the egg of an EggDestroy event.
+	Player string `json:"player,omitempty"`
+	// Target is the player killed in a Kill event.
+	Target string `json:"target,omitempty"`
+	// Team is the colour of the team of Player, or of the team whose egg was
+	// destroyed for EggDestroy events.
+	Team     string  `json:"team,omitempty"`
+	Weapon   string  `json:"weapon,omitempty"`
+	Distance float64 `json:"distance,omitempty"`
+	// Final is true for kills that eliminated the player killed.
+	Final    bool   `json:"final,omitempty"`
+	Item     string `json:"item,omitempty"`
+	Price    int    `json:"price,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Level    int    `json:"level,omitempty"`
+	// Winner is the colour of the team that won, set for End events. It is
+	// empty if the match ended without a winner.
+	Winner    string     `json:"winner,omitempty"`
+	Standings []Standing `json:"standings,omitempty"`
+}
+
+// Standing holds how a player finished a match.
+type Standing struct {
+	Place   int    `json:"place"`
+	Player  string `json:"player"`
+	Team    string `json:"team"`
+	Kills   int    `json:"kills"`
+	Assists int    `json:"assists"`
+	Deaths  int    `json:"deaths"`
+	Eggs    int    `json:"eggs"`
+	Won     bool   `json:"won"`
+}
+
+// Score returns the score used to pick the MVP of a match.
+func (s Standing) Score() int {
+	return s.Kills*2 + s.Assists + s.Eggs*3
+}
+
+// Log holds the events of a single match. Events are kept in memory while the
+// match runs and written to a JSONL file, one event per line, by Save.
+type Log struct {
+	ID string
+
+	mu     sync.Mutex
+	events []Event
+}
+
+// New returns an empty Log for a match in the arena passed, started at the
+// time passed.
+func New(arena string, start time.Time) *Log {
+	return &Log{ID: fmt.Sprintf("%s-%s", arena, start.Format("20060102-150405"))}
+}
+
+// Add adds e to the end of the Log.
+func (l *Log) Add(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+// Events returns a copy of all events in the Log.
+func (l *Log) Events() []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Event(nil), l.events...)
+}
+
+// Save writes the Log to <id>.jsonl in dir, creating dir if needed.
+func (l *Log) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, l.ID+".jsonl")
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range l.Events() {
+		if err := enc.Encode(e); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load reads the Log with the ID passed from dir.
+func Load(dir, id string) (*Log, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return nil, fmt.Errorf("invalid match ID %q", id)
+	}
+	f, err := os.Open(filepath.Join(dir, id+".jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l := &Log{ID: id}
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		var e Event
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("match %s: %w", id, err)
+		}
+		l.events = append(l.events, e)
+	}
+	return l, s.Err()
+}
+
+// List returns the IDs of all matches logged in dir, latest first.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	type match struct {
+		id  string
+		mod time.Time
+	}
+	var matches []match
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".jsonl")
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		matches = append(matches, match{id: id, mod: info.ModTime()})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].mod.After(matches[j].mod)
+	})
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.id
+	}
+	return ids, nil
+}
@@ -0,0 +1,114 @@
+package match
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Summary holds the highlights of a match, shown to players once it is over.
+type Summary struct {
+	ID       string
+	Arena    string
+	Mode     string
+	Started  time.Time
+	Duration time.Duration
+	// Winner is the colour of the team that won, or empty if none did.
+	Winner    string
+	Standings []Standing
+	// MVP is the player with the highest Standing.Score. It is empty if
+	// nobody scored.
+	MVP string
+	// MostKills is the player with the most kills, and Kills its kills.
+	MostKills string
+	Kills     int
+	// FirstEgg is the player that destroyed the first egg of the match and
+	// FirstEggTeam the colour of the team the egg belonged to.
+	FirstEgg     string
+	FirstEggTeam string
+}
+
+// Summary returns the Summary of the match logged. Fields for events that did
+// not happen, such as the end of a match still running, are left empty.
+func (l *Log) Summary() Summary {
+	s := Summary{ID: l.ID}
+	var end time.Time
+	for _, e := range l.Events() {
+		switch e.Type {
+		case Start:
+			s.Arena, s.Mode, s.Started = e.Arena, e.Mode, e.Time
+		case EggDestroy:
+			if s.FirstEgg == "" && e.Player != "" {
+				s.FirstEgg, s.FirstEggTeam = e.Player, e.Team
+			}
+		case End:
+			end, s.Winner, s.Standings = e.Time, e.Winner, e.Standings
+		}
+	}
+	if !s.Started.IsZero() && !end.IsZero() {
+		s.Duration = end.Sub(s.Started)
+	}
+
+	best := 0
+	for _, st := range s.Standings {
+		if score := st.Score(); score > best {
+			s.MVP, best = st.Player, score
+		}
+		if st.Kills > s.Kills {
+			s.MostKills, s.Kills = st.Player, st.Kills
+		}
+	}
+	return s
+}
+
+// Lines returns the Summary as lines of text. player and team format the names
+// of players and the colours of teams, for example to colour them.
+func (s Summary) Lines(player func(name string) string, team func(colour string) string) []string {
+	lines := []string{fmt.Sprintf("§7Match §f%s §8(%s)", s.ID, s.Duration.Round(time.Second))}
+	if s.Winner != "" {
+		lines = append(lines, "§7Winner: "+team(s.Winner))
+	} else {
+		lines = append(lines, "§7Winner: §8none")
+	}
+	lines = append(lines, "§7MVP: "+orNone(s.MVP, player))
+	if s.MostKills != "" {
+		lines = append(lines, fmt.Sprintf("§7Most kills: %s §7(%d)", player(s.MostKills), s.Kills))
+	} else {
+		lines = append(lines, "§7Most kills: §8none")
+	}
+	if s.FirstEgg != "" {
+		lines = append(lines, fmt.Sprintf("§7First egg broken: %s §7(%s§7)", player(s.FirstEgg), team(s.FirstEggTeam)))
+	} else {
+		lines = append(lines, "§7First egg broken: §8none")
+	}
+	return lines
+}
+
+// StandingLines returns a line of text for every Standing of the Summary.
+func (s Summary) StandingLines(player func(name string) string) []string {
+	lines := make([]string, len(s.Standings))
+	for i, st := range s.Standings {
+		lines[i] = fmt.Sprintf("§e#%d %s §7%d kills, %d assists, %d deaths, %d eggs",
+			st.Place, player(st.Player), st.Kills, st.Assists, st.Deaths, st.Eggs)
+	}
+	return lines
+}
+
+func orNone(name string, format func(string) string) string {
+	if name == "" {
+		return "§8none"
+	}
+	return format(name)
+}
+
+// Text joins lines into a single block of text, as used in forms.
+func Text(lines ...[]string) string {
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(strings.Join(l, "\n"))
+	}
+	return b.String()
+}
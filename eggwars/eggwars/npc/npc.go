@@ -0,0 +1,140 @@
+package npc
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"time"
+
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/skin"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/google/uuid"
+)
+
+// Kind is what a shopkeeper offers to the players interacting with it.
+type Kind string
+
+const (
+	// Shop shopkeepers open the item shop.
+	Shop Kind = "shop"
+	// Upgrades shopkeepers let players upgrade their team's generators.
+	Upgrades Kind = "upgrades"
+)
+
+// NPC is a shopkeeper standing on the island of a team. It is a player
+// without a connection that does not move and cannot be hurt.
+type NPC struct {
+	Kind Kind
+	// Team is the name of the team whose island the NPC stands on.
+	Team string
+	Name string
+	Pos  mgl64.Vec3
+	Yaw  float64
+	Skin skin.Skin
+
+	handle *world.EntityHandle
+}
+
+// Spawn adds the NPC to the world of tx. Spawn does nothing if the NPC is
+// already spawned.
+func (n *NPC) Spawn(tx *world.Tx) {
+	if n.handle != nil {
+		return
+	}
+	conf := player.Config{
+		Name:     n.Name,
+		UUID:     uuid.New(),
+		Skin:     n.Skin,
+		GameMode: world.GameModeAdventure,
+		Position: n.Pos,
+		Rotation: cube.Rotation{n.Yaw, 0},
+	}
+	n.handle = world.EntitySpawnOpts{Position: n.Pos, Rotation: conf.Rotation}.New(player.Type, conf)
+	p := tx.AddEntity(n.handle).(*player.Player)
+	p.SetImmobile()
+	p.SetNameTag(n.Name)
+	p.Handle(handler{})
+}
+
+// Is checks if e is the NPC.
+func (n *NPC) Is(e world.Entity) bool {
+	return n.handle != nil && e.H() == n.handle
+}
+
+// Close removes the NPC from the world of tx.
+func (n *NPC) Close(tx *world.Tx) {
+	if n.handle == nil {
+		return
+	}
+	if e, ok := n.handle.Entity(tx); ok {
+		_ = tx.RemoveEntity(e).Close()
+	}
+	n.handle = nil
+}
+
+// handler keeps NPCs from being hurt or getting hungry.
+type handler struct {
+	player.NopHandler
+}
+
+func (handler) HandleHurt(ctx *player.Context, _ *float64, _ bool, _ *time.Duration, _ world.DamageSource) {
+	ctx.Cancel()
+}
+
+func (handler) HandleFoodLoss(ctx *player.Context, _ int, _ *int) {
+	ctx.Cancel()
+}
+
+// LoadSkin reads a skin from the PNG file at path. The image must be 64x64 or
+// 128x128 pixels.
+func LoadSkin(path string) (skin.Skin, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return skin.Skin{}, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return skin.Skin{}, fmt.Errorf("decode %s: %w", path, err)
+	}
+	b := img.Bounds()
+	if (b.Dx() != 64 || b.Dy() != 64) && (b.Dx() != 128 || b.Dy() != 128) {
+		return skin.Skin{}, fmt.Errorf("skin %s is %dx%d, expected 64x64 or 128x128", path, b.Dx(), b.Dy())
+	}
+	s := newSkin(b.Dx(), b.Dy())
+	rgba := &image.RGBA{Pix: s.Pix, Stride: b.Dx() * 4, Rect: image.Rect(0, 0, b.Dx(), b.Dy())}
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return s, nil
+}
+
+// PlainSkin returns a skin with a plain head and clothes in the colour c.
+func PlainSkin(c color.RGBA) skin.Skin {
+	s := newSkin(64, 64)
+	head := color.RGBA{R: 0xc6, G: 0x86, B: 0x42, A: 0xff}
+	for y := 0; y < 64; y++ {
+		px := c
+		if y < 16 {
+			px = head
+		}
+		for x := 0; x < 64; x++ {
+			i := (y*64 + x) * 4
+			s.Pix[i], s.Pix[i+1], s.Pix[i+2], s.Pix[i+3] = px.R, px.G, px.B, px.A
+		}
+	}
+	return s
+}
+
+// newSkin returns an empty skin of the size passed using the standard
+// humanoid model.
+func newSkin(w, h int) skin.Skin {
+	s := skin.New(w, h)
+	s.ModelConfig = skin.ModelConfig{Default: "geometry.humanoid.custom"}
+	return s
+}
@@ -0,0 +1,499 @@
+package party
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+
+	"github.com/df-mc/dragonfly/server/player"
+)
+
+// Party is a group of players that queue for matches and play together.
+type Party struct {
+	// ID is the ID of the party, used as the group players of the party
+	// queue with.
+	ID string
+	// Leader is the name of the player leading the party. Only the leader
+	// may invite players and join arenas for the party.
+	Leader string
+	// Members holds the names of all players in the party, including the
+	// leader, in the order they joined.
+	Members []string
+}
+
+// invite is an invitation of a player to a party.
+type invite struct {
+	party *Party
+	// from is the name of the player that sent the invite.
+	from  string
+	timer *time.Timer
+}
+
+// Manager keeps track of all parties and the invites to them.
+type Manager struct {
+	cfg config.PartyConfig
+	// send sends a message to the online player with the name passed. It
+	// returns false if the player is not online.
+	send func(name, message string) bool
+
+	mu      sync.Mutex
+	next    int
+	parties map[string]*Party
+	// invites holds the pending invites of every player, keyed by the name
+	// of the player invited, oldest first.
+	invites map[string][]*invite
+}
+
+// New creates a Manager that limits parties as configured in cfg. send is
+// used to message players other than the one running a command.
+func New(cfg config.PartyConfig, send func(name, message string) bool) *Manager {
+	return &Manager{
+		cfg:     cfg,
+		send:    send,
+		parties: make(map[string]*Party),
+		invites: make(map[string][]*invite),
+	}
+}
+
+// Of returns a copy of the party of the player with the name passed. It
+// returns false if the player is not in a party.
+func (m *Manager) Of(name string) (Party, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pa, ok := m.parties[name]
+	if !ok {
+		return Party{}, false
+	}
+	c := *pa
+	c.Members = slices.Clone(pa.Members)
+	return c, true
+}
+
+// Invite invites the player with the name passed to the party of p. A new
+// party led by p is created if p is not in one yet.
+func (m *Manager) Invite(p *player.Player, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if strings.EqualFold(name, p.Name()) {
+		p.Message("§c✗ You cannot invite yourself.")
+		return
+	}
+	pa, ok := m.parties[p.Name()]
+	if ok && pa.Leader != p.Name() {
+		p.Message("§c✗ Only the party leader can invite players.")
+		return
+	}
+	if ok && len(pa.Members) >= m.cfg.MaxSize {
+		p.Message(fmt.Sprintf("§c✗ Your party is full (%d/%d).", len(pa.Members), m.cfg.MaxSize))
+		return
+	}
+	if other, ok := m.parties[name]; ok {
+		if other == pa {
+			p.Message(fmt.Sprintf("§c✗ %s is already in your party.", name))
+		} else {
+			p.Message(fmt.Sprintf("§c✗ %s is already in another party.", name))
+		}
+		return
+	}
+	if pa != nil && m.invited(name, pa) != nil {
+		p.Message(fmt.Sprintf("§c✗ %s was already invited to your party.", name))
+		return
+	}
+
+	timeout := time.Duration(m.cfg.InviteTimeout) * time.Second
+	if !m.send(name, fmt.Sprintf("§d%s §einvited you to their party! Use §a/party accept %s §eor §c/party deny %s§e. The invite expires in %s.",
+		p.Name(), p.Name(), p.Name(), timeout)) {
+		p.Message(fmt.Sprintf("§c✗ %s is not online.", name))
+		return
+	}
+	if pa == nil {
+		pa = m.create(p.Name())
+	}
+	inv := &invite{party: pa, from: p.Name()}
+	inv.timer = time.AfterFunc(timeout, func() {
+		m.expire(name, inv)
+	})
+	m.invites[name] = append(m.invites[name], inv)
+	p.Message(fmt.Sprintf("§a✓ Invited %s to your party.", name))
+}
+
+// create creates a party led by the player with the name passed. m.mu must be
+// held.
+func (m *Manager) create(leader string) *Party {
+	m.next++
+	pa := &Party{ID: fmt.Sprintf("party-%d", m.next), Leader: leader, Members: []string{leader}}
+	m.parties[leader] = pa
+	return pa
+}
+
+// invited returns the pending invite of the player with the name passed to
+// pa, or nil if there is none. m.mu must be held.
+func (m *Manager) invited(name string, pa *Party) *invite {
+	for _, inv := range m.invites[name] {
+		if inv.party == pa {
+			return inv
+		}
+	}
+	return nil
+}
+
+// find returns the invite of the player with the name passed that was sent
+// by from. If from is empty, the latest invite is returned. m.mu must be held.
+func (m *Manager) find(name, from string) *invite {
+	invites := m.invites[name]
+	if from == "" {
+		if len(invites) == 0 {
+			return nil
+		}
+		return invites[len(invites)-1]
+	}
+	for _, inv := range invites {
+		if strings.EqualFold(inv.from, from) || strings.EqualFold(inv.party.Leader, from) {
+			return inv
+		}
+	}
+	return nil
+}
+
+// removeInvite removes inv from the invites of the player with the name
+// passed. m.mu must be held.
+func (m *Manager) removeInvite(name string, inv *invite) {
+	inv.timer.Stop()
+	invites := slices.DeleteFunc(m.invites[name], func(other *invite) bool {
+		return other == inv
+	})
+	if len(invites) == 0 {
+		delete(m.invites, name)
+	} else {
+		m.invites[name] = invites
+	}
+	m.cleanup(inv.party)
+}
+
+// expire removes inv once it expired, if it was not answered yet.
+func (m *Manager) expire(name string, inv *invite) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !slices.Contains(m.invites[name], inv) {
+		return
+	}
+	m.removeInvite(name, inv)
+	m.send(name, fmt.Sprintf("§eThe party invite from %s expired.", inv.from))
+	m.send(inv.from, fmt.Sprintf("§eYour party invite to %s expired.", name))
+}
+
+// Accept joins p to the party it was invited to by the player with the name
+// passed. If the name is empty, the latest invite is accepted.
+func (m *Manager) Accept(p *player.Player, from string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inv := m.find(p.Name(), from)
+	if inv == nil {
+		p.Message("§c✗ You have no pending party invite" + fromSuffix(from) + ".")
+		return
+	}
+	if _, ok := m.parties[p.Name()]; ok {
+		p.Message("§c✗ You are already in a party. Use /party leave first.")
+		return
+	}
+	pa := inv.party
+	if len(pa.Members) >= m.cfg.MaxSize {
+		m.removeInvite(p.Name(), inv)
+		p.Message("§c✗ That party is full.")
+		return
+	}
+	m.broadcast(pa, fmt.Sprintf("§d%s §ajoined the party!", p.Name()))
+	pa.Members = append(pa.Members, p.Name())
+	m.parties[p.Name()] = pa
+	m.removeInvite(p.Name(), inv)
+	p.Message(fmt.Sprintf("§a✓ You joined the party of %s.", pa.Leader))
+}
+
+// Deny declines the party invite p received from the player with the name
+// passed. If the name is empty, the latest invite is declined.
+func (m *Manager) Deny(p *player.Player, from string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inv := m.find(p.Name(), from)
+	if inv == nil {
+		p.Message("§c✗ You have no pending party invite" + fromSuffix(from) + ".")
+		return
+	}
+	m.removeInvite(p.Name(), inv)
+	m.send(inv.from, fmt.Sprintf("§c%s declined your party invite.", p.Name()))
+	p.Message(fmt.Sprintf("§eDeclined the party invite from %s.", inv.from))
+}
+
+func fromSuffix(from string) string {
+	if from == "" {
+		return ""
+	}
+	return " from " + from
+}
+
+// Kick removes the player with the name passed from the party led by p.
+func (m *Manager) Kick(p *player.Player, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pa, ok := m.leading(p)
+	if !ok {
+		return
+	}
+	member, ok := member(pa, name)
+	if !ok {
+		p.Message(fmt.Sprintf("§c✗ %s is not in your party.", name))
+		return
+	}
+	if member == p.Name() {
+		p.Message("§c✗ You cannot kick yourself. Use /party leave or /party disband.")
+		return
+	}
+	for _, other := range pa.Members {
+		if other != member {
+			m.send(other, fmt.Sprintf("§d%s §ewas kicked from the party.", member))
+		}
+	}
+	m.send(member, "§cYou were kicked from the party.")
+	m.remove(pa, member)
+}
+
+// Leave removes p from its party. If p led the party, the member that joined
+// it first becomes the new leader.
+func (m *Manager) Leave(p *player.Player) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pa, ok := m.parties[p.Name()]
+	if !ok {
+		p.Message("§c✗ You are not in a party.")
+		return
+	}
+	m.leave(pa, p.Name())
+	p.Message("§eYou left the party.")
+}
+
+// Quit removes the player with the name passed from its party and drops its
+// invites, once it leaves the server.
+func (m *Manager) Quit(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, inv := range slices.Clone(m.invites[name]) {
+		m.removeInvite(name, inv)
+	}
+	if pa, ok := m.parties[name]; ok {
+		m.leave(pa, name)
+	}
+}
+
+// leave removes the player with the name passed from pa and tells the
+// remaining members. m.mu must be held.
+func (m *Manager) leave(pa *Party, name string) {
+	for _, other := range pa.Members {
+		if other != name {
+			m.send(other, fmt.Sprintf("§d%s §eleft the party.", name))
+		}
+	}
+	wasLeader := pa.Leader == name
+	m.remove(pa, name)
+	if wasLeader && len(pa.Members) > 0 {
+		m.broadcast(pa, fmt.Sprintf("§d%s §eis now the party leader.", pa.Leader))
+	}
+}
+
+// remove removes the player with the name passed from pa, passing on the lead
+// if needed. m.mu must be held.
+func (m *Manager) remove(pa *Party, name string) {
+	pa.Members = slices.DeleteFunc(pa.Members, func(member string) bool {
+		return member == name
+	})
+	delete(m.parties, name)
+	if pa.Leader == name && len(pa.Members) > 0 {
+		pa.Leader = pa.Members[0]
+	}
+	if leader := pa.Leader; m.cleanup(pa) && leader != name {
+		m.send(leader, "§eYour party was disbanded, as nobody else is left in it.")
+	}
+}
+
+// cleanup disbands pa if nobody is left in it, or if only its leader is left
+// and nobody is invited to it anymore. It returns true if the party was disbanded. m.mu must be
+// held.
+func (m *Manager) cleanup(pa *Party) bool {
+	if len(pa.Members) > 1 {
+		return false
+	}
+	for _, invites := range m.invites {
+		if len(pa.Members) == 0 {
+			break
+		}
+		for _, inv := range invites {
+			if inv.party == pa {
+				return false
+			}
+		}
+	}
+	m.disband(pa)
+	return true
+}
+
+// dropInvites removes all pending invites to pa. m.mu must be held.
+func (m *Manager) dropInvites(pa *Party) {
+	for name, invites := range m.invites {
+		for _, inv := range invites {
+			if inv.party == pa {
+				inv.timer.Stop()
+			}
+		}
+		invites = slices.DeleteFunc(invites, func(inv *invite) bool {
+			return inv.party == pa
+		})
+		if len(invites) == 0 {
+			delete(m.invites, name)
+		} else {
+			m.invites[name] = invites
+		}
+	}
+}
+
+// disband removes all members and invites of pa. m.mu must be held.
+func (m *Manager) disband(pa *Party) {
+	m.dropInvites(pa)
+	for _, member := range pa.Members {
+		delete(m.parties, member)
+	}
+	pa.Members = nil
+}
+
+// Disband disbands the party led by p.
+func (m *Manager) Disband(p *player.Player) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pa, ok := m.leading(p)
+	if !ok {
+		return
+	}
+	for _, member := range pa.Members {
+		if member != p.Name() {
+			m.send(member, "§eThe party was disbanded by its leader.")
+		}
+	}
+	m.disband(pa)
+	p.Message("§eYou disbanded the party.")
+}
+
+// Transfer makes the member of p's party with the name passed its new leader.
+func (m *Manager) Transfer(p *player.Player, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pa, ok := m.leading(p)
+	if !ok {
+		return
+	}
+	member, ok := member(pa, name)
+	if !ok {
+		p.Message(fmt.Sprintf("§c✗ %s is not in your party.", name))
+		return
+	}
+	if member == p.Name() {
+		p.Message("§c✗ You already lead the party.")
+		return
+	}
+	pa.Leader = member
+	m.broadcast(pa, fmt.Sprintf("§d%s §eis now the party leader.", member))
+}
+
+// List sends p the members of its party and its pending invites.
+func (m *Manager) List(p *player.Player) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pa, ok := m.parties[p.Name()]
+	if !ok {
+		p.Message("§c✗ You are not in a party. Use /party invite <player> to create one.")
+		if invites := m.invites[p.Name()]; len(invites) > 0 {
+			froms := make([]string, len(invites))
+			for i, inv := range invites {
+				froms[i] = inv.from
+			}
+			p.Message("§ePending invites from: §f" + strings.Join(froms, "§7, §f"))
+		}
+		return
+	}
+	p.Message(fmt.Sprintf("§6Party §7(%d/%d):", len(pa.Members), m.cfg.MaxSize))
+	for _, member := range pa.Members {
+		if member == pa.Leader {
+			p.Message("§f  " + member + " §6(leader)")
+		} else {
+			p.Message("§f  " + member)
+		}
+	}
+	var invited []string
+	for name, invites := range m.invites {
+		for _, inv := range invites {
+			if inv.party == pa {
+				invited = append(invited, name)
+			}
+		}
+	}
+	if len(invited) > 0 {
+		slices.Sort(invited)
+		p.Message("§7Invited: " + strings.Join(invited, ", "))
+	}
+}
+
+// Chat sends a message from p to all members of its party.
+func (m *Manager) Chat(p *player.Player, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pa, ok := m.parties[p.Name()]
+	if !ok {
+		p.Message("§c✗ You are not in a party.")
+		return
+	}
+	m.broadcast(pa, fmt.Sprintf("§d[Party] §f%s§7: §f%s", p.Name(), message))
+}
+
+// leading returns the party led by p. If p does not lead a party, it is told
+// so and false is returned. m.mu must be held.
+func (m *Manager) leading(p *player.Player) (*Party, bool) {
+	pa, ok := m.parties[p.Name()]
+	if !ok {
+		p.Message("§c✗ You are not in a party.")
+		return nil, false
+	}
+	if pa.Leader != p.Name() {
+		p.Message("§c✗ Only the party leader can do that.")
+		return nil, false
+	}
+	return pa, true
+}
+
+// broadcast sends a message to all members of pa. m.mu must be held.
+func (m *Manager) broadcast(pa *Party, message string) {
+	for _, member := range pa.Members {
+		m.send(member, message)
+	}
+}
+
+// member returns the name of the member of pa with the name passed, ignoring
+// case.
+func member(pa *Party, name string) (string, bool) {
+	for _, member := range pa.Members {
+		if strings.EqualFold(member, name) {
+			return member, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,85 @@
+package eggwars
+
+import (
+	"fmt"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/arena"
+
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/form"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// RejoinArena brings p back into the match it disconnected from, as long as
+// the time it has to rejoin has not passed.
+func (gm *GameManager) RejoinArena(p *player.Player) bool {
+	pd := gm.GetPlayerDataTyped(p.Name())
+	if pd == nil {
+		p.Message("§c✗ Error: Player data not found! Please reconnect.")
+		return false
+	}
+	if pd.Arena != nil {
+		p.Message(fmt.Sprintf("§c✗ You are already in arena '%s'! Use /leave first.", pd.Arena.Name))
+		return false
+	}
+
+	a := gm.rejoinable(p, pd.ID)
+	if a == nil {
+		p.Message("§c✗ You have no match to rejoin.")
+		return false
+	}
+	old, ok := a.Rejoin(p, pd.ID)
+	if !ok {
+		p.Message(fmt.Sprintf("§c✗ Your match in arena '%s' is over.", a.Name))
+		return false
+	}
+	// The data p had in the match holds its kills and team, so it replaces
+	// the data p got when it connected.
+	gm.mu.Lock()
+	gm.players[p.Name()] = old
+	gm.mu.Unlock()
+	return true
+}
+
+// rejoinable returns the arena p disconnected from during a match that it
+// may still rejoin, or nil if there is none. id is the ID of p's stats.
+func (gm *GameManager) rejoinable(p *player.Player, id string) *arena.Arena {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	for _, a := range gm.arenas {
+		if a.Rejoinable(p, id) {
+			return a
+		}
+	}
+	return nil
+}
+
+// offerRejoin asks p, who just connected, if it wants to go back to its match
+// in a.
+func (gm *GameManager) offerRejoin(p *player.Player, a *arena.Arena) {
+	p.H().ExecWorld(func(tx *world.Tx, e world.Entity) {
+		p := e.(*player.Player)
+		p.Message(fmt.Sprintf("§eYour match in arena '%s' is still running! Use /rejoin to go back.", a.Name))
+		p.SendForm(form.NewModal(rejoinModal{
+			gm:     gm,
+			Rejoin: form.NewButton("§aRejoin", ""),
+			Stay:   form.NewButton("Stay in lobby", ""),
+		}, "§6Match in Progress").
+			WithBody(fmt.Sprintf("You disconnected from a match in arena '%s'. Go back to your team?", a.Name)))
+	})
+}
+
+// rejoinModal is the ModalSubmittable offering a player to rejoin its match.
+type rejoinModal struct {
+	gm     *GameManager
+	Rejoin form.Button
+	Stay   form.Button
+}
+
+func (m rejoinModal) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok || pressed != m.Rejoin {
+		return
+	}
+	m.gm.RejoinArena(p)
+}
@@ -0,0 +1,692 @@
+package setup
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/arena"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/npc"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/team"
+
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/block/cube/trace"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/debug"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/sirupsen/logrus"
+)
+
+// GeneratorTypes holds the types of generators that may be added to an arena.
+var GeneratorTypes = []string{"iron", "gold", "diamond"}
+
+// NPCKinds holds the kinds of shopkeepers that may be placed on islands.
+var NPCKinds = []string{string(npc.Shop), string(npc.Upgrades)}
+
+// reach is the distance up to which admins can pick blocks they look at.
+const reach = 8
+
+// Wizard guides admins through setting up arenas in-game. Every admin edits
+// one arena at a time in a session. The arena is only written to the
+// configuration and loaded once the admin saves it.
+type Wizard struct {
+	cfg    *config.Config
+	arenas Store
+	lobby  *world.World
+	log    *logrus.Logger
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// session is the arena an admin is currently setting up.
+type session struct {
+	name  string
+	arena *config.ArenaConfig
+
+	// w is a copy of the arena's template map the admin edits on. It is nil
+	// until a world is picked.
+	w      *world.World
+	closeW func() error
+}
+
+// Store holds the arenas set up by a Wizard. Arenas are running while they are
+// set up, so a Store must only change the arenas it shares with them once a
+// new arena is saved.
+type Store interface {
+	// ArenaConfig returns the configuration of the saved arena with the name
+	// passed.
+	ArenaConfig(name string) (*config.ArenaConfig, bool)
+	// SaveArena writes a to the configuration file as the arena with the
+	// name passed, replacing the arena with that name if there is one.
+	SaveArena(name string, a *config.ArenaConfig) error
+	// ReloadArena loads the saved arena with the name passed without a
+	// restart.
+	ReloadArena(name string) error
+}
+
+// New creates a Wizard that saves arenas to arenas. cfg is used for the
+// directories of the maps that arenas are set up on.
+func New(cfg *config.Config, arenas Store, lobby *world.World, log *logrus.Logger) *Wizard {
+	return &Wizard{
+		cfg:      cfg,
+		arenas:   arenas,
+		lobby:    lobby,
+		log:      log,
+		sessions: make(map[string]*session),
+	}
+}
+
+// Create starts setting up a new arena with the name passed.
+func (wz *Wizard) Create(p *player.Player, name string) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	if _, ok := wz.sessions[p.Name()]; ok {
+		p.Message("§c✗ You are already setting up an arena. Use /ewadmin save or /ewadmin cancel first.")
+		return
+	}
+	if _, ok := wz.arenas.ArenaConfig(name); ok {
+		p.Message(fmt.Sprintf("§c✗ Arena '%s' already exists. Use /ewadmin edit %s to change it.", name, name))
+		return
+	}
+	if wz.editing(name) {
+		p.Message(fmt.Sprintf("§c✗ Arena '%s' is already being set up by someone else.", name))
+		return
+	}
+	a := &config.ArenaConfig{
+		MinPlayers: 2,
+		TeamSize:   1,
+		Teams:      make(map[string]*config.TeamConfig),
+	}
+	wz.sessions[p.Name()] = &session{name: name, arena: a}
+	p.Message(fmt.Sprintf("§a✓ Started setting up arena '%s'.", name))
+	p.Message("§eNext: pick its map with /ewadmin world <name>.")
+}
+
+// Edit starts changing the existing arena with the name passed.
+func (wz *Wizard) Edit(p *player.Player, name string) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	if _, ok := wz.sessions[p.Name()]; ok {
+		p.Message("§c✗ You are already setting up an arena. Use /ewadmin save or /ewadmin cancel first.")
+		return
+	}
+	a, ok := wz.arenas.ArenaConfig(name)
+	if !ok {
+		p.Message(fmt.Sprintf("§c✗ Arena '%s' not found!", name))
+		return
+	}
+	if wz.editing(name) {
+		p.Message(fmt.Sprintf("§c✗ Arena '%s' is already being set up by someone else.", name))
+		return
+	}
+	s := &session{name: name, arena: a.Clone()}
+	wz.sessions[p.Name()] = s
+	p.Message(fmt.Sprintf("§a✓ Editing arena '%s'.", name))
+	wz.openWorld(p, s)
+}
+
+// SetWorld picks the template map of the arena being set up and moves the
+// admin to a copy of it.
+func (wz *Wizard) SetWorld(p *player.Player, name string) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.session(p)
+	if !ok {
+		return
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) || name == ".." {
+		p.Message("§c✗ Invalid map name.")
+		return
+	}
+	dir := filepath.Join(wz.cfg.MapsDir, name)
+	if _, err := os.Stat(filepath.Join(dir, "level.dat")); err != nil {
+		p.Message(fmt.Sprintf("§c✗ No map found at %s.", dir))
+		return
+	}
+	if err := wz.closeWorld(p, s); err != nil {
+		wz.log.Errorf("Failed to close setup world: %v", err)
+	}
+	s.arena.World = name
+	wz.openWorld(p, s)
+}
+
+// SetLobby sets the spot players wait at for the match to start to the
+// admin's position. The admin must be in the lobby.
+func (wz *Wizard) SetLobby(p *player.Player) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.session(p)
+	if !ok {
+		return
+	}
+	if p.Tx().World() != wz.lobby {
+		p.Message("§c✗ The lobby spawn must be set in the lobby. Use /ewadmin lobby while standing there.")
+		return
+	}
+	s.arena.LobbySpawn = p.Position()
+	p.Message(fmt.Sprintf("§a✓ Lobby spawn set to %s.", vec(p.Position())))
+}
+
+// AddTeam adds a team with the colour passed, spawning at the admin's
+// position.
+func (wz *Wizard) AddTeam(p *player.Player, colour string) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.inMap(p)
+	if !ok {
+		return
+	}
+	if _, ok := team.ParseColor(colour); !ok {
+		p.Message(fmt.Sprintf("§c✗ Unknown colour %s.", colour))
+		return
+	}
+	if _, ok := s.arena.Teams[colour]; ok {
+		p.Message(fmt.Sprintf("§c✗ Team %s already exists.", colour))
+		return
+	}
+	if len(s.arena.Teams) >= config.MaxTeams {
+		p.Message(fmt.Sprintf("§c✗ An arena can have at most %d teams.", config.MaxTeams))
+		return
+	}
+	s.arena.Teams[colour] = &config.TeamConfig{Spawn: p.Position()}
+	p.Message(fmt.Sprintf("§a✓ Added team %s, spawning at %s.", colour, vec(p.Position())))
+	p.Message(fmt.Sprintf("§eNext: look at its egg and use /ewadmin egg %s.", colour))
+	wz.draw(p, s)
+}
+
+// SetTeamSpawn sets the spawn of a team to the admin's position.
+func (wz *Wizard) SetTeamSpawn(p *player.Player, colour string) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.inMap(p)
+	if !ok {
+		return
+	}
+	t, ok := s.arena.Teams[colour]
+	if !ok {
+		p.Message(fmt.Sprintf("§c✗ Team %s does not exist. Add it with /ewadmin team %s.", colour, colour))
+		return
+	}
+	t.Spawn = p.Position()
+	p.Message(fmt.Sprintf("§a✓ Spawn of team %s set to %s.", colour, vec(p.Position())))
+	wz.draw(p, s)
+}
+
+// SetEgg sets the egg of a team to the block the admin is looking at.
+func (wz *Wizard) SetEgg(p *player.Player, colour string) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.inMap(p)
+	if !ok {
+		return
+	}
+	t, ok := s.arena.Teams[colour]
+	if !ok {
+		p.Message(fmt.Sprintf("§c✗ Team %s does not exist. Add it with /ewadmin team %s.", colour, colour))
+		return
+	}
+	pos, ok := lookingAt(p)
+	if !ok {
+		p.Message("§c✗ Look at the block the egg should be at.")
+		return
+	}
+	t.Egg = pos
+	p.Message(fmt.Sprintf("§a✓ Egg of team %s set to %s.", colour, pos))
+	if t.Shop == nil {
+		p.Message(fmt.Sprintf("§eNext: stand where its shopkeeper should be and use /ewadmin npc shop %s.", colour))
+	}
+	wz.draw(p, s)
+}
+
+// SetNPC places the shopkeeper of the kind passed of a team at the admin's
+// position, facing the way the admin faces.
+func (wz *Wizard) SetNPC(p *player.Player, kind, colour string) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.inMap(p)
+	if !ok {
+		return
+	}
+	t, ok := s.arena.Teams[colour]
+	if !ok {
+		p.Message(fmt.Sprintf("§c✗ Team %s does not exist. Add it with /ewadmin team %s.", colour, colour))
+		return
+	}
+	n := &config.NPCConfig{Pos: p.Position(), Yaw: p.Rotation().Yaw()}
+	switch npc.Kind(kind) {
+	case npc.Shop:
+		t.Shop = n
+	case npc.Upgrades:
+		t.Upgrades = n
+	default:
+		p.Message(fmt.Sprintf("§c✗ Unknown shopkeeper %s.", kind))
+		return
+	}
+	p.Message(fmt.Sprintf("§a✓ Shopkeeper (%s) of team %s set to %s.", kind, colour, vec(n.Pos)))
+	wz.draw(p, s)
+}
+
+// AddGenerator adds a generator of the type passed on the block the admin is
+// standing on. team may be empty for a generator shared by all teams.
+func (wz *Wizard) AddGenerator(p *player.Player, typ, owner string) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.inMap(p)
+	if !ok {
+		return
+	}
+	if _, ok := s.arena.Teams[owner]; owner != "" && !ok {
+		p.Message(fmt.Sprintf("§c✗ Team %s does not exist.", owner))
+		return
+	}
+	g := &config.GeneratorConfig{
+		Type:     typ,
+		Team:     owner,
+		Position: cube.PosFromVec3(p.Position()).Side(cube.FaceDown),
+	}
+	if typ == "iron" || owner == "" {
+		// Team iron and shared generators run from the start, the others
+		// must be activated by the team first.
+		g.Level = 1
+	}
+	s.arena.Generators = append(s.arena.Generators, g)
+	p.Message(fmt.Sprintf("§a✓ Added %s generator at %s.", typ, g.Position))
+	wz.draw(p, s)
+}
+
+// SetCorner sets one of the two corners of the arena's bounds to the block
+// the admin is standing in.
+func (wz *Wizard) SetCorner(p *player.Player, i int) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.inMap(p)
+	if !ok {
+		return
+	}
+	s.arena.Bounds[i] = cube.PosFromVec3(p.Position())
+	p.Message(fmt.Sprintf("§a✓ Corner %d of the bounds set to %s.", i+1, s.arena.Bounds[i]))
+	wz.draw(p, s)
+}
+
+// SetVoid sets the void level of the arena to the admin's Y position.
+func (wz *Wizard) SetVoid(p *player.Player) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.inMap(p)
+	if !ok {
+		return
+	}
+	s.arena.VoidY = math.Floor(p.Position().Y())
+	p.Message(fmt.Sprintf("§a✓ Players now die below Y %.0f.", s.arena.VoidY))
+}
+
+// SetBuildHeight sets the build height of the arena to the admin's Y
+// position.
+func (wz *Wizard) SetBuildHeight(p *player.Player) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.inMap(p)
+	if !ok {
+		return
+	}
+	s.arena.BuildHeight = cube.PosFromVec3(p.Position()).Y()
+	p.Message(fmt.Sprintf("§a✓ Blocks may now be placed up to Y %d.", s.arena.BuildHeight))
+}
+
+// SetPlayers sets the minimum amount of players of the arena and the size of
+// its teams.
+func (wz *Wizard) SetPlayers(p *player.Player, min, teamSize int) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.session(p)
+	if !ok {
+		return
+	}
+	if min < 1 || teamSize < 1 {
+		p.Message("§c✗ The minimum and the team size must be at least 1.")
+		return
+	}
+	s.arena.MinPlayers, s.arena.TeamSize = min, teamSize
+	p.Message(fmt.Sprintf("§a✓ Arena needs at least %d players, in teams of %d (%s).", min, teamSize, s.arena.Mode()))
+}
+
+// Validate sends the admin every problem that prevents the arena from being
+// saved.
+func (wz *Wizard) Validate(p *player.Player) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.session(p)
+	if !ok {
+		return
+	}
+	problems := wz.problems(s)
+	if len(problems) == 0 {
+		p.Message(fmt.Sprintf("§a✓ Arena '%s' is ready to be saved.", s.name))
+		return
+	}
+	p.Message(fmt.Sprintf("§eArena '%s' has %d problem(s):", s.name, len(problems)))
+	for _, problem := range problems {
+		p.Message("§c - " + problem)
+	}
+}
+
+// Save validates the arena and writes it to the configuration file, which
+// drops any comments in the file. The arena is loaded right away and the
+// admin is brought back to the lobby.
+func (wz *Wizard) Save(p *player.Player) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.session(p)
+	if !ok {
+		return
+	}
+	if problems := wz.problems(s); len(problems) > 0 {
+		p.Message("§c✗ The arena can't be saved yet:")
+		for _, problem := range problems {
+			p.Message("§c - " + problem)
+		}
+		return
+	}
+	if err := wz.arenas.SaveArena(s.name, s.arena); err != nil {
+		wz.log.Errorf("Failed to save arena %s: %v", s.name, err)
+		p.Message("§c✗ The arena could not be saved, see the console for details.")
+		return
+	}
+	wz.log.Infof("%s saved arena %s", p.Name(), s.name)
+	p.Message(fmt.Sprintf("§a✓ Saved arena '%s'.", s.name))
+
+	wz.end(p, s)
+	if err := wz.arenas.ReloadArena(s.name); err != nil {
+		p.Message(fmt.Sprintf("§e%v. It will be loaded once the server restarts.", err))
+		return
+	}
+	p.Message(fmt.Sprintf("§a✓ Arena '%s' is now open: /join %s", s.name, s.name))
+}
+
+// Cancel stops setting up the arena without saving it.
+func (wz *Wizard) Cancel(p *player.Player) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.session(p)
+	if !ok {
+		return
+	}
+	wz.end(p, s)
+	p.Message(fmt.Sprintf("§e Stopped setting up arena '%s' without saving.", s.name))
+}
+
+// Info sends the admin the current setup of the arena.
+func (wz *Wizard) Info(p *player.Player) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	s, ok := wz.session(p)
+	if !ok {
+		return
+	}
+	a := s.arena
+	p.Message(fmt.Sprintf("§6Arena '%s'", s.name))
+	p.Message(fmt.Sprintf("§7Map: §f%s", a.World))
+	p.Message(fmt.Sprintf("§7Players: §f%d-%d §7(%s)", a.MinPlayers, len(a.Teams)*a.TeamSize, a.Mode()))
+	p.Message(fmt.Sprintf("§7Lobby spawn: §f%s", vec(a.LobbySpawn)))
+	for _, name := range sortedTeams(a) {
+		t := a.Teams[name]
+		p.Message(fmt.Sprintf("§7Team %s: §fspawn %s, egg %s", name, vec(t.Spawn), t.Egg))
+	}
+	p.Message(fmt.Sprintf("§7Generators: §f%d", len(a.Generators)))
+	p.Message(fmt.Sprintf("§7Bounds: §f%s to %s", a.Bounds[0], a.Bounds[1]))
+	p.Message(fmt.Sprintf("§7Void level: §f%.0f§7, build height: §f%d", a.VoidY, a.BuildHeight))
+}
+
+// Close ends all sessions, removing the copies of the maps edited on.
+func (wz *Wizard) Close() {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	for name, s := range wz.sessions {
+		if s.closeW != nil {
+			if err := s.closeW(); err != nil {
+				wz.log.Errorf("Failed to close setup world: %v", err)
+			}
+		}
+		delete(wz.sessions, name)
+	}
+}
+
+// problems returns every problem that prevents the arena of s from being
+// saved.
+func (wz *Wizard) problems(s *session) []string {
+	a := s.arena
+	var problems []string
+	if a.World == "" {
+		problems = append(problems, "No map picked (/ewadmin world <name>).")
+	} else if _, err := os.Stat(filepath.Join(wz.cfg.MapsDir, a.World, "level.dat")); err != nil {
+		problems = append(problems, fmt.Sprintf("Map %s does not exist.", a.World))
+	}
+	if a.LobbySpawn == (mgl64.Vec3{}) {
+		problems = append(problems, "No lobby spawn set (/ewadmin lobby).")
+	}
+	if len(a.Teams) < config.MinTeams {
+		problems = append(problems, fmt.Sprintf("At least %d teams are needed (/ewadmin team <colour>).", config.MinTeams))
+	}
+	if len(a.Teams) > config.MaxTeams {
+		problems = append(problems, fmt.Sprintf("At most %d teams are allowed.", config.MaxTeams))
+	}
+	if a.MinPlayers > len(a.Teams)*a.TeamSize {
+		problems = append(problems, "More players are needed to start than fit in the teams (/ewadmin players).")
+	}
+	inBounds := a.InBounds
+	if !a.Bounded() {
+		problems = append(problems, "No bounds set (/ewadmin pos1 and /ewadmin pos2).")
+	}
+	for _, name := range sortedTeams(a) {
+		t := a.Teams[name]
+		if t.Egg == (cube.Pos{}) {
+			problems = append(problems, fmt.Sprintf("Team %s has no egg (/ewadmin egg %s).", name, name))
+		} else if !inBounds(t.Egg) {
+			problems = append(problems, fmt.Sprintf("The egg of team %s is out of bounds.", name))
+		}
+		if !inBounds(cube.PosFromVec3(t.Spawn)) {
+			problems = append(problems, fmt.Sprintf("The spawn of team %s is out of bounds.", name))
+		}
+		if t.Spawn.Y() < a.VoidY {
+			problems = append(problems, fmt.Sprintf("The spawn of team %s is below the void level (/ewadmin void).", name))
+		}
+		if t.Shop == nil {
+			problems = append(problems, fmt.Sprintf("Team %s has no shopkeeper (/ewadmin npc shop %s).", name, name))
+		}
+		for _, n := range []*config.NPCConfig{t.Shop, t.Upgrades} {
+			if n != nil && !inBounds(cube.PosFromVec3(n.Pos)) {
+				problems = append(problems, fmt.Sprintf("A shopkeeper of team %s is out of bounds.", name))
+			}
+		}
+	}
+	for i, g := range a.Generators {
+		if _, ok := a.Teams[g.Team]; g.Team != "" && !ok {
+			problems = append(problems, fmt.Sprintf("Generator %d belongs to team %s, which does not exist.", i+1, g.Team))
+		}
+		if !inBounds(g.Position) {
+			problems = append(problems, fmt.Sprintf("Generator %d is out of bounds.", i+1))
+		}
+	}
+	return problems
+}
+
+// session returns the session of p, telling p if it has none. wz.mu must be
+// held.
+func (wz *Wizard) session(p *player.Player) (*session, bool) {
+	s, ok := wz.sessions[p.Name()]
+	if !ok {
+		p.Message("§c✗ You are not setting up an arena. Use /ewadmin create <name> or /ewadmin edit <name>.")
+	}
+	return s, ok
+}
+
+// inMap returns the session of p if p is in the map of the arena it is
+// setting up. wz.mu must be held.
+func (wz *Wizard) inMap(p *player.Player) (*session, bool) {
+	s, ok := wz.session(p)
+	if !ok {
+		return nil, false
+	}
+	if s.w == nil || p.Tx().World() != s.w {
+		p.Message("§c✗ You must be in the arena's map for this. Use /ewadmin world <name> to go there.")
+		return nil, false
+	}
+	return s, true
+}
+
+// editing checks if an arena with the name passed is being set up. wz.mu must
+// be held.
+func (wz *Wizard) editing(name string) bool {
+	for _, s := range wz.sessions {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// openWorld opens a copy of the template map of the arena of s and moves p to
+// it. wz.mu must be held.
+func (wz *Wizard) openWorld(p *player.Player, s *session) {
+	if s.arena.World == "" {
+		return
+	}
+	w, closeW, err := arena.OpenCopy("setup-"+s.name, wz.cfg.TemplateDir(s.arena), wz.cfg.InstancesDir, wz.lobby.EntityRegistry())
+	if err != nil {
+		wz.log.Errorf("Failed to open map %s for setup: %v", s.arena.World, err)
+		p.Message("§c✗ The map could not be opened, see the console for details.")
+		return
+	}
+	s.w, s.closeW = w, closeW
+	p.SetGameMode(world.GameModeCreative)
+	p.MoveToWorld(w, w.Spawn().Vec3Middle())
+	p.Message(fmt.Sprintf("§a✓ You are now in map %s. Changes to the map itself are not saved.", s.arena.World))
+	wz.draw(p, s)
+}
+
+// closeWorld closes the map of s, after moving p back to the lobby if it is
+// still in it. wz.mu must be held.
+func (wz *Wizard) closeWorld(p *player.Player, s *session) error {
+	if s.w == nil {
+		return nil
+	}
+	p.RemoveAllDebugShapes()
+	if p.Tx().World() == s.w {
+		p.SetGameMode(world.GameModeSurvival)
+		p.MoveToWorld(wz.lobby, wz.lobby.Spawn().Vec3Middle())
+	}
+	w, closeW := s.w, s.closeW
+	s.w, s.closeW = nil, nil
+	// The world can only be closed once p has left it, which happens at the
+	// end of the current transaction.
+	go func() {
+		<-w.Exec(func(*world.Tx) {})
+		if err := closeW(); err != nil {
+			wz.log.Errorf("Failed to close setup world: %v", err)
+		}
+	}()
+	return nil
+}
+
+// end ends the session of p. wz.mu must be held.
+func (wz *Wizard) end(p *player.Player, s *session) {
+	if err := wz.closeWorld(p, s); err != nil {
+		wz.log.Errorf("Failed to close setup world: %v", err)
+	}
+	delete(wz.sessions, p.Name())
+}
+
+// draw shows the spawns, eggs, generators and bounds of the arena of s to p
+// using debug shapes.
+func (wz *Wizard) draw(p *player.Player, s *session) {
+	p.RemoveAllDebugShapes()
+	a := s.arena
+	for name, t := range a.Teams {
+		c := team.Color(name).RGBA()
+		p.AddDebugShape(&debug.Text{Colour: c, Position: t.Spawn.Add(mgl64.Vec3{0, 2}), Text: name + " spawn"})
+		p.AddDebugShape(&debug.Circle{Colour: c, Position: t.Spawn, Scale: 0.5})
+		if t.Egg != (cube.Pos{}) {
+			p.AddDebugShape(&debug.Box{Colour: c, Position: t.Egg.Vec3()})
+			p.AddDebugShape(&debug.Text{Colour: c, Position: t.Egg.Vec3Middle().Add(mgl64.Vec3{0, 1.5}), Text: name + " egg"})
+		}
+		for kind, n := range map[string]*config.NPCConfig{"shop": t.Shop, "upgrades": t.Upgrades} {
+			if n != nil {
+				p.AddDebugShape(&debug.Text{Colour: c, Position: n.Pos.Add(mgl64.Vec3{0, 2.5}), Text: name + " " + kind})
+				p.AddDebugShape(&debug.Circle{Colour: c, Position: n.Pos, Scale: 0.3})
+			}
+		}
+	}
+	for _, g := range a.Generators {
+		label := g.Type + " generator"
+		if g.Team != "" {
+			label = g.Team + " " + label
+		}
+		p.AddDebugShape(&debug.Box{Position: g.Position.Vec3()})
+		p.AddDebugShape(&debug.Text{Position: g.Position.Vec3Middle().Add(mgl64.Vec3{0, 1.5}), Text: label})
+	}
+	if a.Bounded() {
+		lo, hi := a.Corners()
+		p.AddDebugShape(&debug.Box{
+			Colour:   color.RGBA{R: 0xff, G: 0xaa, A: 0xff},
+			Position: lo.Vec3(),
+			Bounds:   hi.Vec3().Sub(lo.Vec3()).Add(mgl64.Vec3{1, 1, 1}),
+		})
+	}
+}
+
+// lookingAt returns the first solid block p is looking at within reach.
+func lookingAt(p *player.Player) (cube.Pos, bool) {
+	start := p.Position().Add(mgl64.Vec3{0, p.EyeHeight()})
+	end := start.Add(p.Rotation().Vec3().Mul(reach))
+
+	var found cube.Pos
+	ok := false
+	trace.TraverseBlocks(start, end, func(pos cube.Pos) bool {
+		if _, air := p.Tx().Block(pos).(block.Air); !air {
+			found, ok = pos, true
+			return false
+		}
+		return true
+	})
+	return found, ok
+}
+
+func sortedTeams(a *config.ArenaConfig) []string {
+	names := make([]string, 0, len(a.Teams))
+	for name := range a.Teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func vec(v mgl64.Vec3) string {
+	return fmt.Sprintf("(%.1f, %.1f, %.1f)", v[0], v[1], v[2])
+}
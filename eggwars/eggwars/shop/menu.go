@@ -0,0 +1,125 @@
+package shop
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/form"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// Open sends the main page of the shop, listing all categories, to p, who buys
+// as the Customer c.
+func (s *Shop) Open(p *player.Player, c Customer) {
+	m := categoryMenu{s: s, cu: c}
+	buttons := make([]form.Button, 0, len(Categories))
+	for _, cat := range Categories {
+		if len(s.offers[cat]) == 0 {
+			continue
+		}
+		m.categories = append(m.categories, cat)
+		buttons = append(buttons, cat.button())
+	}
+	if len(buttons) == 0 {
+		p.Message("§c✗ The shop has nothing for sale.")
+		return
+	}
+	p.SendForm(form.NewMenu(m, "§6EggWars Shop").
+		WithBody(balance(c.Wallet), "\n\nSelect a category:").
+		WithButtons(buttons...))
+}
+
+// categoryMenu is the main page of the shop, with a button per category.
+type categoryMenu struct {
+	s          *Shop
+	cu         Customer
+	categories []Category
+}
+
+func (m categoryMenu) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok {
+		return
+	}
+	for _, c := range m.categories {
+		if pressed == c.button() {
+			m.s.openCategory(p, m.cu, c)
+			return
+		}
+	}
+}
+
+func (s *Shop) openCategory(p *player.Player, cu Customer, c Category) {
+	offers := s.offers[c]
+	buttons := make([]form.Button, 0, len(offers))
+	for _, o := range offers {
+		buttons = append(buttons, o.button())
+	}
+	buttons = uniqueButtons(buttons)
+	m := offerMenu{s: s, cu: cu, c: c, offers: offers, buttons: buttons, Back: form.NewButton("§8« Back", "")}
+	p.SendForm(form.NewMenu(m, c.Title()).
+		WithBody(balance(cu.Wallet)).
+		WithButtons(buttons...))
+}
+
+// offerMenu is a category page of the shop, with a button per offer. The
+// offer bought is the one at the index of the button pressed in buttons.
+type offerMenu struct {
+	s       *Shop
+	cu      Customer
+	c       Category
+	offers  []Offer
+	buttons []form.Button
+	Back    form.Button
+}
+
+func (m offerMenu) Submit(submitter form.Submitter, pressed form.Button, tx *world.Tx) {
+	p, ok := submitter.(*player.Player)
+	if !ok {
+		return
+	}
+	if pressed == m.Back {
+		m.s.Open(p, m.cu)
+		return
+	}
+	if i := slices.Index(m.buttons, pressed); i >= 0 && m.offers[i].Buy(p, m.cu) {
+		m.s.openCategory(p, m.cu, m.c)
+	}
+}
+
+// uniqueButtons makes buttons that look the same distinguishable by appending
+// invisible format codes to their text. Forms only report the button that was
+// pressed rather than its position, so buttons with the same text and image
+// could otherwise not be told apart.
+func uniqueButtons(buttons []form.Button) []form.Button {
+	seen := make(map[form.Button]int, len(buttons))
+	for i, b := range buttons {
+		n := seen[b]
+		seen[b] = n + 1
+		if n > 0 {
+			buttons[i].Text += strings.Repeat("§r", n)
+		}
+	}
+	return buttons
+}
+
+func (c Category) button() form.Button {
+	return form.NewButton(c.Title(), c.Icon())
+}
+
+func (o Offer) button() form.Button {
+	return form.NewButton(fmt.Sprintf("§f%s\n§7Cost: %d %s", o.Name, o.Price, currencyName(o.Currency)), "")
+}
+
+func balance(w Wallet) string {
+	return fmt.Sprintf("Your resources: §7%d Iron §6%d Gold §b%d Diamond §a%d Emerald§r", w.Balance("iron"), w.Balance("gold"), w.Balance("diamond"), w.Balance("emerald"))
+}
+
+func currencyName(currency string) string {
+	if currency == "" {
+		return ""
+	}
+	return strings.ToUpper(currency[:1]) + currency[1:]
+}
@@ -0,0 +1,335 @@
+package shop
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+	"github.com/eggwars-dragonfly/eggwars/eggwars/utility"
+
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/item/inventory"
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/sirupsen/logrus"
+)
+
+type Category string
+
+const (
+	Blocks  Category = "blocks"
+	Weapons Category = "weapons"
+	Armour  Category = "armour"
+	Tools   Category = "tools"
+	Potions Category = "potions"
+	Utility Category = "utility"
+)
+
+// Categories holds all categories in the order they are shown in the shop.
+var Categories = []Category{Blocks, Weapons, Armour, Tools, Potions, Utility}
+
+func (c Category) Title() string {
+	switch c {
+	case Blocks:
+		return "§fBlocks"
+	case Weapons:
+		return "§cWeapons"
+	case Armour:
+		return "§9Armour"
+	case Tools:
+		return "§7Tools"
+	case Potions:
+		return "§dPotions"
+	default:
+		return "§eUtility"
+	}
+}
+
+func (c Category) Icon() string {
+	switch c {
+	case Blocks:
+		return "textures/blocks/wool_colored_white"
+	case Weapons:
+		return "textures/items/iron_sword"
+	case Armour:
+		return "textures/items/iron_chestplate"
+	case Tools:
+		return "textures/items/iron_pickaxe"
+	case Potions:
+		return "textures/items/potion_bottle_splash_heal"
+	default:
+		return "textures/blocks/tnt_side"
+	}
+}
+
+// Wallet holds the currency a player can spend in the shop.
+type Wallet interface {
+	// Balance returns how much of a currency is available.
+	Balance(currency string) int
+	// Take removes an amount of a currency. It returns false, without taking
+	// anything, if not enough of the currency is available.
+	Take(currency string, amount int) bool
+	// Give adds an amount of a currency, such as to refund a purchase.
+	Give(currency string, amount int)
+}
+
+// permanentKey is the key of the value that marks items players keep when
+// they die.
+const permanentKey = "eggwars:permanent"
+
+// IsPermanent checks if s is a permanent upgrade, which players keep when they
+// die.
+func IsPermanent(s item.Stack) bool {
+	_, ok := s.Value(permanentKey)
+	return ok
+}
+
+// Tint changes an item bought to fit the buyer, such as dyeing wool in the
+// colour of the buyer's team.
+type Tint func(s item.Stack) item.Stack
+
+// Customer holds how a player buying in the shop pays and what is done with
+// the items it buys.
+type Customer struct {
+	Wallet Wallet
+	// Tint, if not nil, is applied to every item bought.
+	Tint Tint
+	// Bought, if not nil, is called after every offer bought.
+	Bought func(o Offer)
+}
+
+// CurrencyItem returns the item that a currency is paid with.
+func CurrencyItem(currency string) (world.Item, bool) {
+	switch currency {
+	case "iron":
+		return item.IronIngot{}, true
+	case "gold":
+		return item.GoldIngot{}, true
+	case "diamond":
+		return item.Diamond{}, true
+	case "emerald":
+		return item.Emerald{}, true
+	}
+	return nil, false
+}
+
+// InventoryWallet is a Wallet that pays with the currency items, such as iron
+// ingots, held in an inventory.
+type InventoryWallet struct {
+	Inv *inventory.Inventory
+}
+
+func (w InventoryWallet) Balance(currency string) int {
+	it, ok := CurrencyItem(currency)
+	if !ok {
+		return 0
+	}
+	n := 0
+	for _, s := range w.Inv.Items() {
+		if isCurrency(s, it) {
+			n += s.Count()
+		}
+	}
+	return n
+}
+
+func (w InventoryWallet) Take(currency string, amount int) bool {
+	it, ok := CurrencyItem(currency)
+	if !ok || w.Balance(currency) < amount {
+		return false
+	}
+	return w.Inv.RemoveItemFunc(amount, func(s item.Stack) bool {
+		return isCurrency(s, it)
+	}) == nil
+}
+
+func (w InventoryWallet) Give(currency string, amount int) {
+	it, ok := CurrencyItem(currency)
+	if !ok || amount <= 0 {
+		return
+	}
+	_, _ = w.Inv.AddItem(item.NewStack(it, amount))
+}
+
+// isCurrency checks if s is a plain stack of the currency item it. Renamed or
+// enchanted stacks are not accepted as payment.
+func isCurrency(s item.Stack, it world.Item) bool {
+	return s.Comparable(item.NewStack(it, 1))
+}
+
+// Offer is a single item that can be bought in the shop.
+type Offer struct {
+	Name     string
+	Category Category
+	Price    int
+	Currency string
+	Stack    item.Stack
+}
+
+// Buy buys the Offer for p, paying with the Wallet of c. The item is only paid
+// for if it fits in p's inventory.
+func (o Offer) Buy(p *player.Player, c Customer) bool {
+	s, w := o.Stack, c.Wallet
+	if c.Tint != nil {
+		s = c.Tint(s)
+	}
+	if have := w.Balance(o.Currency); have < o.Price {
+		p.Message(fmt.Sprintf("§c✗ Not enough %s! Need: %d, Have: %d", o.Currency, o.Price, have))
+		return false
+	}
+
+	inv := p.Inventory()
+	if n, err := inv.AddItem(s); err != nil {
+		if n > 0 {
+			_ = inv.RemoveItem(s.Grow(n - s.Count()))
+		}
+		p.Message("§c✗ Your inventory is full!")
+		return false
+	}
+	if !w.Take(o.Currency, o.Price) {
+		_ = inv.RemoveItem(s)
+		p.Message(fmt.Sprintf("§c✗ Not enough %s!", o.Currency))
+		return false
+	}
+
+	p.Message(fmt.Sprintf("§a✓ Purchased %s!", o.Name))
+	if c.Bought != nil {
+		c.Bought(o)
+	}
+	return true
+}
+
+// Shop holds the offers of the shop, grouped by category.
+type Shop struct {
+	offers map[Category][]Offer
+}
+
+// New builds a Shop from the configuration passed. Items that cannot be
+// resolved are logged and left out of the shop.
+func New(cfg *config.ShopConfig, log *logrus.Logger) *Shop {
+	s := &Shop{offers: make(map[Category][]Offer)}
+	if cfg == nil {
+		return s
+	}
+
+	keys := make([]string, 0, len(cfg.Items))
+	for k := range cfg.Items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		o, err := parseOffer(cfg.Items[k])
+		if err != nil {
+			log.Warnf("Skipping shop item %s: %v", k, err)
+			continue
+		}
+		s.offers[o.Category] = append(s.offers[o.Category], o)
+	}
+	for _, offers := range s.offers {
+		sort.SliceStable(offers, func(i, j int) bool {
+			ri, rj := currencyRank(offers[i].Currency), currencyRank(offers[j].Currency)
+			return ri < rj || ri == rj && offers[i].Price < offers[j].Price
+		})
+	}
+	return s
+}
+
+// Offers returns the offers listed in a category.
+func (s *Shop) Offers(c Category) []Offer {
+	return s.offers[c]
+}
+
+func parseOffer(cfg *config.ShopItem) (Offer, error) {
+	c := Category(strings.ToLower(cfg.Category))
+	if c == "" {
+		c = Utility
+	}
+	known := false
+	for _, other := range Categories {
+		known = known || c == other
+	}
+	if !known {
+		return Offer{}, fmt.Errorf("unknown category %q", cfg.Category)
+	}
+	if _, ok := CurrencyItem(cfg.Currency); !ok || cfg.Price < 0 {
+		return Offer{}, fmt.Errorf("invalid price %d %q", cfg.Price, cfg.Currency)
+	}
+
+	meta := cfg.Meta
+	if cfg.Potion != 0 {
+		meta = int16(cfg.Potion)
+	}
+	stack, err := NewStack(cfg.Item, meta, cfg.Amount, cfg.Enchantments)
+	if err != nil {
+		return Offer{}, err
+	}
+	if cfg.CustomName != "" {
+		stack = stack.WithCustomName(cfg.CustomName)
+	}
+	if cfg.Permanent {
+		stack = stack.WithValue(permanentKey, true).WithLore("§6Permanent upgrade")
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Item
+	}
+	return Offer{Name: name, Category: c, Price: cfg.Price, Currency: cfg.Currency, Stack: stack}, nil
+}
+
+// NewStack creates a stack of amount of the item with the name and meta
+// passed, such as "minecraft:iron_sword" or utility.TrackerName, enchanted with the enchantments
+// passed. Enchantments map enchantment names, such as "sharpness", to levels.
+// An amount of 0 or less is treated as 1.
+func NewStack(name string, meta int16, amount int, enchantments map[string]int) (item.Stack, error) {
+	amount = max(amount, 1)
+	var stack item.Stack
+	if name == utility.TrackerName {
+		stack = utility.NewTracker().Grow(amount - 1)
+	} else {
+		it, ok := world.ItemByName(name, meta)
+		if !ok {
+			return item.Stack{}, fmt.Errorf("unknown item %q", name)
+		}
+		stack = item.NewStack(it, amount)
+	}
+	if amount > stack.MaxCount() {
+		return item.Stack{}, fmt.Errorf("amount %d exceeds max stack size %d", amount, stack.MaxCount())
+	}
+
+	for name, lvl := range enchantments {
+		t, ok := enchantmentByName(name)
+		if !ok {
+			return item.Stack{}, fmt.Errorf("unknown enchantment %q", name)
+		}
+		stack = stack.WithEnchantments(item.NewEnchantment(t, lvl))
+	}
+	return stack, nil
+}
+
+// currencyRank orders currencies from the most to the least common.
+func currencyRank(currency string) int {
+	switch currency {
+	case "iron":
+		return 0
+	case "gold":
+		return 1
+	case "diamond":
+		return 2
+	}
+	return 3
+}
+
+// enchantmentByName looks up a registered enchantment by its name, ignoring
+// case and treating underscores as spaces.
+func enchantmentByName(name string) (item.EnchantmentType, bool) {
+	name = strings.ReplaceAll(name, "_", " ")
+	for _, t := range item.Enchantments() {
+		if strings.EqualFold(t.Name(), name) {
+			return t, true
+		}
+	}
+	return nil, false
+}
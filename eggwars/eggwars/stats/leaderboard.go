@@ -0,0 +1,153 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Stat is a stat that players may be ranked by.
+type Stat string
+
+const (
+	Rating Stat = "rating"
+	Wins   Stat = "wins"
+	Kills  Stat = "kills"
+	KD     Stat = "kd"
+)
+
+// Stats holds all stats that players may be ranked by.
+var Stats = []Stat{Rating, Wins, Kills, KD}
+
+// Title returns the name of the stat as shown to players.
+func (s Stat) Title() string {
+	switch s {
+	case Rating:
+		return "Rating"
+	case Wins:
+		return "Wins"
+	case Kills:
+		return "Kills"
+	case KD:
+		return "K/D Ratio"
+	}
+	return string(s)
+}
+
+// Format formats a value of the stat.
+func (s Stat) Format(v float64) string {
+	switch s {
+	case Rating:
+		return fmt.Sprintf("%.0f", v)
+	case KD:
+		return fmt.Sprintf("%.2f", v)
+	}
+	return fmt.Sprintf("%d", int(v))
+}
+
+// Window is the time frame that players are ranked over.
+type Window string
+
+const (
+	AllTime Window = "all"
+	Weekly  Window = "weekly"
+	Daily   Window = "daily"
+)
+
+// Windows holds all windows that players may be ranked over.
+var Windows = []Window{AllTime, Weekly, Daily}
+
+// Title returns the name of the window as shown to players.
+func (w Window) Title() string {
+	switch w {
+	case Weekly:
+		return "This Week"
+	case Daily:
+		return "Today"
+	}
+	return "All Time"
+}
+
+// Start returns the start of the window that t falls in: midnight of the day
+// for Daily and midnight of the Monday of the week for Weekly. AllTime
+// windows have no start, so the zero time is returned for them.
+func (w Window) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch w {
+	case Daily:
+		return day
+	case Weekly:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return time.Time{}
+}
+
+// Entry is the position of a player on a leaderboard.
+type Entry struct {
+	ID    string
+	Name  string
+	Value float64
+}
+
+// Top returns the n players ranked highest by s within the window w that the
+// time now falls in, best first. Only players that played a match within the
+// window are ranked. Players are ranked by rating in every window, as ratings
+// are not kept per window.
+func (sm *StatsManager) Top(s Stat, w Window, now time.Time, n int) ([]Entry, error) {
+	all, err := sm.store.All()
+	if err != nil {
+		return nil, err
+	}
+	sm.mu.Lock()
+	for id, st := range sm.stats {
+		// Cached stats may have changes not saved to the store yet.
+		all[id] = *st
+	}
+	sm.mu.Unlock()
+
+	start := w.Start(now)
+	entries := make([]Entry, 0, len(all))
+	for id, st := range all {
+		sm.init(&st)
+		kills, deaths, wins, games := st.Kills, st.Deaths, st.Wins, st.Games
+		switch w {
+		case Daily:
+			kills, deaths, wins, games = st.Daily.Kills, st.Daily.Deaths, st.Daily.Wins, st.Daily.Games
+			if !st.Daily.Start.Equal(start) {
+				games = 0
+			}
+		case Weekly:
+			kills, deaths, wins, games = st.Weekly.Kills, st.Weekly.Deaths, st.Weekly.Wins, st.Weekly.Games
+			if !st.Weekly.Start.Equal(start) {
+				games = 0
+			}
+		}
+		if games == 0 {
+			continue
+		}
+		e := Entry{ID: id, Name: st.Name}
+		switch s {
+		case Rating:
+			e.Value = st.Rating
+		case Wins:
+			e.Value = float64(wins)
+		case Kills:
+			e.Value = float64(kills)
+		case KD:
+			e.Value = float64(kills) / float64(max(deaths, 1))
+		default:
+			return nil, fmt.Errorf("unknown stat %q", s)
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries, nil
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/df-mc/goleveldb/leveldb"
+	"github.com/df-mc/goleveldb/leveldb/opt"
+)
+
+// LevelDBStore is a Store that keeps stats in a LevelDB database. The stats
+// of each player are stored as JSON under the player's ID.
+type LevelDBStore struct {
+	db *leveldb.DB
+}
+
+// NewLevelDBStore opens the LevelDB database in the directory at path as a
+// Store, creating it if it does not exist.
+func NewLevelDBStore(path string) (*LevelDBStore, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		_ = os.Mkdir(path, 0777)
+	}
+	db, err := leveldb.OpenFile(path, &opt.Options{Compression: opt.SnappyCompression})
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDBStore{db: db}, nil
+}
+
+// Load ...
+func (s *LevelDBStore) Load(id string) (PlayerStats, bool, error) {
+	b, err := s.db.Get([]byte(id), nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return PlayerStats{}, false, nil
+	} else if err != nil {
+		return PlayerStats{}, false, err
+	}
+	var st PlayerStats
+	if err := json.Unmarshal(b, &st); err != nil {
+		return PlayerStats{}, false, err
+	}
+	return st, true, nil
+}
+
+// Save ...
+func (s *LevelDBStore) Save(batch map[string]PlayerStats) error {
+	b := new(leveldb.Batch)
+	for id, st := range batch {
+		data, err := json.Marshal(st)
+		if err != nil {
+			return err
+		}
+		b.Put([]byte(id), data)
+	}
+	return s.db.Write(b, nil)
+}
+
+// All ...
+func (s *LevelDBStore) All() (map[string]PlayerStats, error) {
+	all := make(map[string]PlayerStats)
+	it := s.db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		var st PlayerStats
+		if err := json.Unmarshal(it.Value(), &st); err != nil {
+			return nil, err
+		}
+		all[string(it.Key())] = st
+	}
+	return all, it.Error()
+}
+
+// Close ...
+func (s *LevelDBStore) Close() error {
+	return s.db.Close()
+}
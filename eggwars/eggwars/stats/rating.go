@@ -0,0 +1,91 @@
+package stats
+
+import "math"
+
+// Placing is the result of a single team in a match.
+type Placing struct {
+	// Players holds the IDs of the players of the team.
+	Players []string
+	// Place is the place the team finished in, 1 being the winner. Teams
+	// with the same place tied.
+	Place int
+}
+
+// Rate updates the ratings of the players of a match from the places their
+// teams finished in. Every team is compared with every other team, as in a
+// multiplayer Elo rating: a team gains rating for every team it finished
+// above and loses rating for every team it finished below, more so the
+// stronger or weaker that team was. Rate returns the change in rating of
+// every player, keyed by ID.
+// The stats of the players should be recorded first, so that their names are
+// known.
+func (sm *StatsManager) Rate(placings []Placing) map[string]float64 {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	teams := make([][]*PlayerStats, 0, len(placings))
+	places := make([]int, 0, len(placings))
+	for _, pl := range placings {
+		if len(pl.Players) == 0 {
+			continue
+		}
+		team := make([]*PlayerStats, len(pl.Players))
+		for i, id := range pl.Players {
+			team[i] = sm.get(id, "")
+		}
+		teams = append(teams, team)
+		places = append(places, pl.Place)
+	}
+
+	ratings := make([]float64, len(teams))
+	for i, team := range teams {
+		for _, s := range team {
+			ratings[i] += s.Rating
+		}
+		ratings[i] /= float64(len(team))
+	}
+	deltas := ratingChanges(ratings, places, sm.cfg.RatingK)
+
+	changes := make(map[string]float64)
+	i := 0
+	for _, pl := range placings {
+		if len(pl.Players) == 0 {
+			continue
+		}
+		for j, id := range pl.Players {
+			teams[i][j].Rating += deltas[i]
+			changes[id] = deltas[i]
+			sm.dirty[id] = struct{}{}
+		}
+		i++
+	}
+	return changes
+}
+
+// ratingChanges returns the change in rating of every team of a match, given
+// the average rating of its players and the place it finished in. k is the
+// most a team's rating may change by.
+func ratingChanges(ratings []float64, places []int, k float64) []float64 {
+	deltas := make([]float64, len(ratings))
+	if len(ratings) < 2 {
+		return deltas
+	}
+	for i := range ratings {
+		var sum float64
+		for j := range ratings {
+			if i == j {
+				continue
+			}
+			expected := 1 / (1 + math.Pow(10, (ratings[j]-ratings[i])/400))
+			actual := 0.5
+			if places[i] < places[j] {
+				actual = 1
+			} else if places[i] > places[j] {
+				actual = 0
+			}
+			sum += actual - expected
+		}
+		deltas[i] = k * sum / float64(len(ratings)-1)
+	}
+	return deltas
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRatingChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		ratings []float64
+		places  []int
+		want    []float64
+	}{
+		{"win against an equal team", []float64{1000, 1000}, []int{1, 2}, []float64{16, -16}},
+		{"loss against an equal team", []float64{1000, 1000}, []int{2, 1}, []float64{-16, 16}},
+		{"draw", []float64{1000, 1000}, []int{1, 1}, []float64{0, 0}},
+		{"underdog wins", []float64{1000, 1400}, []int{1, 2}, []float64{29.09, -29.09}},
+		{"favourite wins", []float64{1400, 1000}, []int{1, 2}, []float64{2.91, -2.91}},
+		{"four equal teams", []float64{1000, 1000, 1000, 1000}, []int{1, 2, 3, 4}, []float64{16, 5.33, -5.33, -16}},
+		{"three teams, two tied last", []float64{1000, 1000, 1000}, []int{1, 2, 2}, []float64{16, -8, -8}},
+		{"single team", []float64{1000}, []int{1}, []float64{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ratingChanges(tt.ratings, tt.places, 32)
+			var sum float64
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 0.01 {
+					t.Errorf("change of team %d: got %.2f, want %.2f", i, got[i], tt.want[i])
+				}
+				sum += got[i]
+			}
+			if math.Abs(sum) > 1e-9 {
+				t.Errorf("changes do not add up to 0: %v", got)
+			}
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+	sm := NewStatsManager(NewMemoryStore(), config.StatsConfig{FlushInterval: 3600, InitialRating: 1000, RatingK: 32}, log)
+	defer sm.Close()
+
+	changes := sm.Rate([]Placing{
+		{Players: []string{"red1", "red2"}, Place: 1},
+		{Players: []string{"blue1", "blue2"}, Place: 2},
+		// Teams without players, such as ones nobody played in, are left
+		// out of the rating.
+		{Place: 3},
+	})
+	want := map[string]float64{"red1": 16, "red2": 16, "blue1": -16, "blue2": -16}
+	if len(changes) != len(want) {
+		t.Fatalf("changes: got %v, want %v", changes, want)
+	}
+	for id, w := range want {
+		if changes[id] != w {
+			t.Errorf("change of %s: got %v, want %v", id, changes[id], w)
+		}
+		if got := sm.GetStats(id, id).Rating; got != 1000+w {
+			t.Errorf("rating of %s: got %v, want %v", id, got, 1000+w)
+		}
+	}
+
+	// The winners are now rated higher, so beating them again gains less.
+	changes = sm.Rate([]Placing{
+		{Players: []string{"red1"}, Place: 1},
+		{Players: []string{"blue1"}, Place: 2},
+	})
+	if c := changes["red1"]; c <= 0 || c >= 16 {
+		t.Errorf("change of red1 after winning again: got %v, want between 0 and 16", c)
+	}
+}
@@ -1,124 +1,261 @@
 package stats
 
 import (
-	"encoding/json"
-	"github.com/sirupsen/logrus"
-	"os"
+	"slices"
 	"sync"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/sirupsen/logrus"
 )
 
 type PlayerStats struct {
-	Name   string `json:"name"`
-	Kills  int    `json:"kills"`
-	Deaths int    `json:"deaths"`
-	Wins   int    `json:"wins"`
-	Losses int    `json:"losses"`
-	Games  int    `json:"games"`
+	// Name is the name the player last played with.
+	Name          string `json:"name"`
+	Kills         int    `json:"kills"`
+	Assists       int    `json:"assists"`
+	Deaths        int    `json:"deaths"`
+	Wins          int    `json:"wins"`
+	Losses        int    `json:"losses"`
+	Games         int    `json:"games"`
+	EggsDestroyed int    `json:"eggs_destroyed"`
+	BlocksPlaced  int    `json:"blocks_placed"`
+	// TimePlayed is the total time the player spent in matches.
+	TimePlayed time.Duration `json:"time_played"`
+	// Rating is the skill rating of the player, updated by
+	// StatsManager.Rate after every match.
+	Rating float64 `json:"rating"`
+	// Daily and Weekly hold the stats of the player in the day and week it
+	// last played in.
+	Daily  Period `json:"daily"`
+	Weekly Period `json:"weekly"`
+	// Coins are earned by playing matches and spent to unlock kits.
+	Coins int `json:"coins"`
+	// Kits holds the IDs of the kits the player unlocked, and Kit the ID of
+	// the kit it picked. Kit is empty if the player never picked one.
+	Kits []string `json:"kits,omitempty"`
+	Kit  string   `json:"kit,omitempty"`
 }
 
-type StatsManager struct {
-	stats map[string]*PlayerStats
-	log   *logrus.Logger
-	mu    sync.RWMutex
+// Period holds the stats of a player within a single day or week.
+type Period struct {
+	// Start is the start of the day or week.
+	Start  time.Time `json:"start"`
+	Kills  int       `json:"kills"`
+	Deaths int       `json:"deaths"`
+	Wins   int       `json:"wins"`
+	Games  int       `json:"games"`
 }
 
-func NewStatsManager(log *logrus.Logger) *StatsManager {
-	sm := &StatsManager{
-		stats: make(map[string]*PlayerStats),
-		log:   log,
+// add adds the results of m to the period starting at start, first clearing
+// the period if it started at another time.
+func (p *Period) add(start time.Time, m Match) {
+	if !p.Start.Equal(start) {
+		*p = Period{Start: start}
 	}
-	
-	sm.load()
-	return sm
+	p.Kills += m.Kills
+	p.Deaths += m.Deaths
+	p.Games++
+	if m.Won {
+		p.Wins++
+	}
+}
+
+// Match holds what a player did in a single match.
+type Match struct {
+	Kills         int
+	Assists       int
+	Deaths        int
+	EggsDestroyed int
+	BlocksPlaced  int
+	Won           bool
+	TimePlayed    time.Duration
+	// Time is the time the match ended.
+	Time time.Time
+	// Coins are the coins earned in the match.
+	Coins int
 }
 
-func (sm *StatsManager) GetStats(playerName string) *PlayerStats {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
-	
-	if stats, ok := sm.stats[playerName]; ok {
-		return stats
+// PlayerID returns the ID that stats of p are stored under. This is the XUID
+// of the player, or its UUID if the player is not authenticated with XBOX
+// Live.
+func PlayerID(p *player.Player) string {
+	if xuid := p.XUID(); xuid != "" {
+		return xuid
 	}
-	
-	return &PlayerStats{
-		Name: playerName,
+	return p.UUID().String()
+}
+
+// StatsManager caches the stats of players in memory. Changes are written to
+// its Store in batches by a background flusher, so that recording a match
+// never waits on the disk.
+type StatsManager struct {
+	store Store
+	cfg   config.StatsConfig
+	log   *logrus.Logger
+
+	mu    sync.Mutex
+	stats map[string]*PlayerStats
+	dirty map[string]struct{}
+
+	closing chan struct{}
+	done    chan struct{}
+}
+
+// NewStatsManager creates a StatsManager that stores stats in store, writing
+// changes to it at the flush interval of cfg.
+func NewStatsManager(store Store, cfg config.StatsConfig, log *logrus.Logger) *StatsManager {
+	sm := &StatsManager{
+		store:   store,
+		cfg:     cfg,
+		log:     log,
+		stats:   make(map[string]*PlayerStats),
+		dirty:   make(map[string]struct{}),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
 	}
+	go sm.run(time.Duration(cfg.FlushInterval) * time.Second)
+	return sm
 }
 
-func (sm *StatsManager) AddKill(playerName string) {
+// GetStats returns the stats of the player with the ID passed. If the player
+// has no stats yet, empty stats with the name passed are returned.
+func (sm *StatsManager) GetStats(id, name string) PlayerStats {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
-	
-	if _, ok := sm.stats[playerName]; !ok {
-		sm.stats[playerName] = &PlayerStats{Name: playerName}
-	}
-	
-	sm.stats[playerName].Kills++
-	sm.save()
+	return *sm.get(id, name)
 }
 
-func (sm *StatsManager) AddDeath(playerName string) {
+// Record adds the results of a match to the stats of the player with the ID
+// passed.
+func (sm *StatsManager) Record(id, name string, m Match) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
-	
-	if _, ok := sm.stats[playerName]; !ok {
-		sm.stats[playerName] = &PlayerStats{Name: playerName}
+
+	s := sm.get(id, name)
+	s.Name = name
+	s.Kills += m.Kills
+	s.Assists += m.Assists
+	s.Deaths += m.Deaths
+	s.EggsDestroyed += m.EggsDestroyed
+	s.BlocksPlaced += m.BlocksPlaced
+	s.TimePlayed += m.TimePlayed
+	s.Coins += m.Coins
+	s.Games++
+	if m.Won {
+		s.Wins++
+	} else {
+		s.Losses++
 	}
-	
-	sm.stats[playerName].Deaths++
-	sm.save()
+	s.Daily.add(Daily.Start(m.Time), m)
+	s.Weekly.add(Weekly.Start(m.Time), m)
+	sm.dirty[id] = struct{}{}
 }
 
-func (sm *StatsManager) AddWin(playerName string) {
+// UnlockKit unlocks the kit with the ID passed for the player with the ID
+// passed, paying cost coins. It returns false if the player does not have
+// enough coins. Kits already unlocked are not paid for again.
+func (sm *StatsManager) UnlockKit(id, name, kit string, cost int) bool {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
-	
-	if _, ok := sm.stats[playerName]; !ok {
-		sm.stats[playerName] = &PlayerStats{Name: playerName}
+
+	s := sm.get(id, name)
+	if slices.Contains(s.Kits, kit) {
+		return true
 	}
-	
-	sm.stats[playerName].Wins++
-	sm.stats[playerName].Games++
-	sm.save()
+	if s.Coins < cost {
+		return false
+	}
+	s.Coins -= cost
+	s.Kits = append(s.Kits, kit)
+	sm.dirty[id] = struct{}{}
+	return true
 }
 
-func (sm *StatsManager) AddLoss(playerName string) {
+// SelectKit sets the kit that the player with the ID passed picked.
+func (sm *StatsManager) SelectKit(id, name, kit string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
-	
-	if _, ok := sm.stats[playerName]; !ok {
-		sm.stats[playerName] = &PlayerStats{Name: playerName}
-	}
-	
-	sm.stats[playerName].Losses++
-	sm.stats[playerName].Games++
-	sm.save()
+
+	s := sm.get(id, name)
+	s.Kit = kit
+	sm.dirty[id] = struct{}{}
 }
 
-func (sm *StatsManager) load() {
-	if _, err := os.Stat("stats.json"); os.IsNotExist(err) {
-		return
+// get returns the cached stats of a player, loading them from the store if
+// they are not cached yet. sm.mu must be held.
+func (sm *StatsManager) get(id, name string) *PlayerStats {
+	if s, ok := sm.stats[id]; ok {
+		return s
 	}
-	
-	data, err := os.ReadFile("stats.json")
+	s, ok, err := sm.store.Load(id)
 	if err != nil {
-		sm.log.Errorf("Failed to load stats: %v", err)
-		return
+		sm.log.Errorf("Failed to load stats of %s: %v", name, err)
 	}
-	
-	if err := json.Unmarshal(data, &sm.stats); err != nil {
-		sm.log.Errorf("Failed to unmarshal stats: %v", err)
+	if !ok {
+		s = PlayerStats{Name: name}
 	}
+	sm.init(&s)
+	sm.stats[id] = &s
+	return &s
 }
 
-func (sm *StatsManager) save() {
-	data, err := json.MarshalIndent(sm.stats, "", "  ")
-	if err != nil {
-		sm.log.Errorf("Failed to marshal stats: %v", err)
+// init fills in the rating of stats that have none yet, such as those of new
+// players or stored before ratings were kept.
+func (sm *StatsManager) init(s *PlayerStats) {
+	if s.Rating == 0 {
+		s.Rating = sm.cfg.InitialRating
+	}
+}
+
+func (sm *StatsManager) run(interval time.Duration) {
+	defer close(sm.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sm.flush()
+		case <-sm.closing:
+			sm.flush()
+			return
+		}
+	}
+}
+
+// flush writes all stats changed since the last flush to the store.
+func (sm *StatsManager) flush() {
+	sm.mu.Lock()
+	if len(sm.dirty) == 0 {
+		sm.mu.Unlock()
 		return
 	}
-	
-	if err := os.WriteFile("stats.json", data, 0644); err != nil {
+	batch := make(map[string]PlayerStats, len(sm.dirty))
+	for id := range sm.dirty {
+		batch[id] = *sm.stats[id]
+	}
+	sm.dirty = make(map[string]struct{})
+	sm.mu.Unlock()
+
+	if err := sm.store.Save(batch); err != nil {
 		sm.log.Errorf("Failed to save stats: %v", err)
+
+		// Mark the batch dirty again so it is retried with the next flush.
+		sm.mu.Lock()
+		for id := range batch {
+			sm.dirty[id] = struct{}{}
+		}
+		sm.mu.Unlock()
 	}
 }
+
+// Close writes all pending changes to the store and closes it.
+func (sm *StatsManager) Close() error {
+	close(sm.closing)
+	<-sm.done
+	return sm.store.Close()
+}
@@ -0,0 +1,140 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"sync"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+)
+
+// Store is a storage backend for the stats of players, keyed by player ID.
+type Store interface {
+	// Load loads the stats of the player with the ID passed. It returns false
+	// if no stats are stored for the player.
+	Load(id string) (PlayerStats, bool, error)
+	// Save stores a batch of stats, overwriting the stats already stored for
+	// the same players.
+	Save(batch map[string]PlayerStats) error
+	// All returns the stats of all players stored, keyed by player ID.
+	All() (map[string]PlayerStats, error)
+	// Close closes the store.
+	Close() error
+}
+
+// NewStore opens the Store configured in cfg.
+func NewStore(cfg config.StatsConfig) (Store, error) {
+	switch cfg.Backend {
+	case "json":
+		return NewJSONStore(cfg.Path)
+	case "leveldb":
+		return NewLevelDBStore(cfg.Path)
+	case "memory":
+		return NewMemoryStore(), nil
+	}
+	return nil, fmt.Errorf("unknown stats backend %q", cfg.Backend)
+}
+
+// MemoryStore is a Store that keeps stats in memory only. Its contents are
+// lost when it is closed.
+type MemoryStore struct {
+	mu    sync.Mutex
+	stats map[string]PlayerStats
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{stats: make(map[string]PlayerStats)}
+}
+
+// Load ...
+func (s *MemoryStore) Load(id string) (PlayerStats, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.stats[id]
+	return st, ok, nil
+}
+
+// Save ...
+func (s *MemoryStore) Save(batch map[string]PlayerStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, st := range batch {
+		s.stats[id] = st
+	}
+	return nil
+}
+
+// All ...
+func (s *MemoryStore) All() (map[string]PlayerStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.stats), nil
+}
+
+// Close ...
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// JSONStore is a Store that keeps all stats in a single JSON file. The file
+// is read once when the store is opened and rewritten on every Save.
+type JSONStore struct {
+	path string
+	mem  *MemoryStore
+}
+
+// NewJSONStore opens the JSON file at path as a Store. The file is created on
+// the first Save if it does not exist.
+func NewJSONStore(path string) (*JSONStore, error) {
+	s := &JSONStore{path: path, mem: NewMemoryStore()}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.mem.stats); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Load ...
+func (s *JSONStore) Load(id string) (PlayerStats, bool, error) {
+	return s.mem.Load(id)
+}
+
+// Save ...
+func (s *JSONStore) Save(batch map[string]PlayerStats) error {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	for id, st := range batch {
+		s.mem.stats[id] = st
+	}
+	data, err := json.MarshalIndent(s.mem.stats, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that a crash halfway through does
+	// not leave a truncated file behind.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// All ...
+func (s *JSONStore) All() (map[string]PlayerStats, error) {
+	return s.mem.All()
+}
+
+// Close ...
+func (s *JSONStore) Close() error {
+	return nil
+}
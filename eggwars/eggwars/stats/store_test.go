@@ -0,0 +1,177 @@
+package stats
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/eggwars-dragonfly/eggwars/eggwars/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// testStats returns stats with every kind of field filled in.
+func testStats(name string) PlayerStats {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return PlayerStats{
+		Name:          name,
+		Kills:         12,
+		Assists:       3,
+		Deaths:        4,
+		Wins:          2,
+		Losses:        1,
+		Games:         3,
+		EggsDestroyed: 1,
+		BlocksPlaced:  64,
+		TimePlayed:    25 * time.Minute,
+		Rating:        1032.5,
+		Daily:         Period{Start: day, Kills: 5, Deaths: 1, Wins: 1, Games: 1},
+		Weekly:        Period{Start: day, Kills: 12, Deaths: 4, Wins: 2, Games: 3},
+		Coins:         150,
+		Kits:          []string{"archer"},
+		Kit:           "archer",
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		// open opens the store kept in dir. It is called again after
+		// closing the store to check what it persisted.
+		open func(dir string) (Store, error)
+		// persists is true if stats survive closing the store.
+		persists bool
+	}{
+		{"memory", func(string) (Store, error) { return NewMemoryStore(), nil }, false},
+		{"json", func(dir string) (Store, error) { return NewJSONStore(filepath.Join(dir, "stats.json")) }, true},
+		{"leveldb", func(dir string) (Store, error) { return NewLevelDBStore(filepath.Join(dir, "stats")) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := tt.open(dir)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			if _, ok, err := s.Load("missing"); ok || err != nil {
+				t.Fatalf("Load of missing player: got %v, %v, want false, nil", ok, err)
+			}
+
+			want := map[string]PlayerStats{"2535400000000001": testStats("Alice"), "2535400000000002": testStats("Bob")}
+			if err := s.Save(want); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			// Saving again overwrites only the players in the batch.
+			alice := want["2535400000000001"]
+			alice.Kills++
+			want["2535400000000001"] = alice
+			if err := s.Save(map[string]PlayerStats{"2535400000000001": alice}); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			checkStore(t, s, want)
+
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if !tt.persists {
+				return
+			}
+			s, err = tt.open(dir)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			defer s.Close()
+			checkStore(t, s, want)
+		})
+	}
+}
+
+// checkStore checks that s holds exactly the stats in want.
+func checkStore(t *testing.T, s Store, want map[string]PlayerStats) {
+	t.Helper()
+	for id, w := range want {
+		got, ok, err := s.Load(id)
+		if err != nil || !ok {
+			t.Fatalf("Load(%s): got %v, %v, want true, nil", id, ok, err)
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("Load(%s):\ngot  %+v\nwant %+v", id, got, w)
+		}
+	}
+	all, err := s.All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("All:\ngot  %+v\nwant %+v", all, want)
+	}
+}
+
+// failingStore is a Store whose first saves fail.
+type failingStore struct {
+	*MemoryStore
+	failures int
+}
+
+func (s *failingStore) Save(batch map[string]PlayerStats) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("disk full")
+	}
+	return s.MemoryStore.Save(batch)
+}
+
+func TestStatsManagerFlush(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+	cfg := config.StatsConfig{FlushInterval: 3600, InitialRating: 1000}
+	end := time.Date(2026, 1, 7, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures int
+	}{
+		{"written on close", 0},
+		{"retried after a failed save", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &failingStore{MemoryStore: NewMemoryStore(), failures: tt.failures}
+			sm := NewStatsManager(store, cfg, log)
+			sm.Record("1", "Alice", Match{Kills: 3, Deaths: 1, Won: true, TimePlayed: 10 * time.Minute, Time: end, Coins: 20})
+			sm.Record("1", "Alice", Match{Kills: 1, Deaths: 2, Time: end.Add(time.Hour)})
+			sm.Record("2", "Bob", Match{Deaths: 4, Time: end})
+
+			// The flush interval is far away, so nothing is written
+			// before an explicit flush.
+			if all, _ := store.All(); len(all) != 0 {
+				t.Fatalf("stats written before flushing: %v", all)
+			}
+			for range tt.failures {
+				sm.flush()
+			}
+			if err := sm.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			alice, ok, _ := store.Load("1")
+			if !ok {
+				t.Fatal("stats of Alice were not written")
+			}
+			if alice.Name != "Alice" || alice.Kills != 4 || alice.Deaths != 3 || alice.Wins != 1 || alice.Losses != 1 || alice.Games != 2 {
+				t.Errorf("stats of Alice: got %+v", alice)
+			}
+			if alice.TimePlayed != 10*time.Minute || alice.Coins != 20 || alice.Rating != 1000 {
+				t.Errorf("stats of Alice: got %+v", alice)
+			}
+			if alice.Daily.Games != 2 || alice.Weekly.Kills != 4 {
+				t.Errorf("periods of Alice: got daily %+v, weekly %+v", alice.Daily, alice.Weekly)
+			}
+			if bob, ok, _ := store.Load("2"); !ok || bob.Losses != 1 || bob.Deaths != 4 {
+				t.Errorf("stats of Bob: got %+v, %v", bob, ok)
+			}
+		})
+	}
+}
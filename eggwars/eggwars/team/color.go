@@ -0,0 +1,131 @@
+package team
+
+import (
+	"image/color"
+
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/item"
+)
+
+// Color is the colour of a team. It is one of the 16 dye colours, named like
+// the colours of item.Colour, such as "red" or "light_blue".
+type Color string
+
+const (
+	White     Color = "white"
+	Orange    Color = "orange"
+	Magenta   Color = "magenta"
+	LightBlue Color = "light_blue"
+	Yellow    Color = "yellow"
+	Lime      Color = "lime"
+	Pink      Color = "pink"
+	Gray      Color = "gray"
+	LightGray Color = "light_gray"
+	Cyan      Color = "cyan"
+	Purple    Color = "purple"
+	Blue      Color = "blue"
+	Brown     Color = "brown"
+	Green     Color = "green"
+	Red       Color = "red"
+	Black     Color = "black"
+)
+
+// Colors holds all colours a team may have, in the order of the dye colours.
+var Colors = []Color{
+	White, Orange, Magenta, LightBlue, Yellow, Lime, Pink, Gray,
+	LightGray, Cyan, Purple, Blue, Brown, Green, Red, Black,
+}
+
+type colorInfo struct {
+	name string
+	code string
+	dye  item.Colour
+}
+
+// colors maps every Color to its name, chat colour code and dye colour. Every
+// team gets a distinct chat colour, so some use the extra colours of Bedrock
+// Edition.
+var colors = map[Color]colorInfo{
+	White:     {"White", "§f", item.ColourWhite()},
+	Orange:    {"Orange", "§6", item.ColourOrange()},
+	Magenta:   {"Magenta", "§u", item.ColourMagenta()},
+	LightBlue: {"Light Blue", "§b", item.ColourLightBlue()},
+	Yellow:    {"Yellow", "§e", item.ColourYellow()},
+	Lime:      {"Lime", "§a", item.ColourLime()},
+	Pink:      {"Pink", "§d", item.ColourPink()},
+	Gray:      {"Gray", "§8", item.ColourGrey()},
+	LightGray: {"Light Gray", "§7", item.ColourLightGrey()},
+	Cyan:      {"Cyan", "§3", item.ColourCyan()},
+	Purple:    {"Purple", "§5", item.ColourPurple()},
+	Blue:      {"Blue", "§9", item.ColourBlue()},
+	Brown:     {"Brown", "§n", item.ColourBrown()},
+	Green:     {"Green", "§2", item.ColourGreen()},
+	Red:       {"Red", "§c", item.ColourRed()},
+	Black:     {"Black", "§0", item.ColourBlack()},
+}
+
+// ParseColor returns the Color with the name passed, such as "light_blue".
+func ParseColor(name string) (Color, bool) {
+	_, ok := colors[Color(name)]
+	return Color(name), ok
+}
+
+// Name returns the name of the colour as shown to players, such as
+// "Light Blue".
+func (c Color) Name() string {
+	return colors[c].name
+}
+
+// Title returns the name of the team of the colour in the colour, such as
+// "§cTeam Red".
+func (c Color) Title() string {
+	return c.Code() + "Team " + c.Name()
+}
+
+// Code returns the chat formatting code of the colour, such as "§c".
+func (c Color) Code() string {
+	return colors[c].code
+}
+
+// Dye returns the dye colour of the colour, used for wool and other coloured
+// blocks.
+func (c Color) Dye() item.Colour {
+	return colors[c].dye
+}
+
+// RGBA returns the colour as RGBA, used for leather armour.
+func (c Color) RGBA() color.RGBA {
+	return c.Dye().RGBA()
+}
+
+// Tint returns s in the colour c if it is wool or leather armour. Any other
+// stack is returned unchanged.
+func (c Color) Tint(s item.Stack) item.Stack {
+	leather := item.ArmourTierLeather{Colour: c.RGBA()}
+	switch it := s.Item().(type) {
+	case block.Wool:
+		it.Colour = c.Dye()
+		return s.WithItem(it)
+	case item.Helmet:
+		if _, ok := it.Tier.(item.ArmourTierLeather); ok {
+			it.Tier = leather
+			return s.WithItem(it)
+		}
+	case item.Chestplate:
+		if _, ok := it.Tier.(item.ArmourTierLeather); ok {
+			it.Tier = leather
+			return s.WithItem(it)
+		}
+	case item.Leggings:
+		if _, ok := it.Tier.(item.ArmourTierLeather); ok {
+			it.Tier = leather
+			return s.WithItem(it)
+		}
+	case item.Boots:
+		if _, ok := it.Tier.(item.ArmourTierLeather); ok {
+			it.Tier = leather
+			return s.WithItem(it)
+		}
+	}
+	return s
+}
@@ -1,43 +1,44 @@
 package team
 
 import (
+	"time"
+
 	"github.com/df-mc/dragonfly/server/block/cube"
 	"github.com/go-gl/mathgl/mgl64"
 )
 
-type Color string
-
-const (
-	Red    Color = "§c"
-	Blue   Color = "§9"
-	Green  Color = "§a"
-	Yellow Color = "§e"
-)
-
 type Team struct {
-	Name       string
-	Color      Color
-	ColorName  string
-	Spawn      mgl64.Vec3
-	EggPos     cube.Pos
-	EggAlive   bool
-	Players    []string
-	Generator  mgl64.Vec3
+	Name     string
+	Color    Color
+	Spawn    mgl64.Vec3
+	EggPos   cube.Pos
+	EggAlive bool
+	Players  []string
+
+	// tiers holds the tiers of the upgrades the team bought, and traps the
+	// traps set on its island. Traps do not trigger before trapsArmed.
+	tiers      map[Upgrade]int
+	traps      []Trap
+	trapsArmed time.Time
 }
 
-func NewTeam(name string, color Color, colorName string, spawn mgl64.Vec3, eggPos cube.Pos, generator mgl64.Vec3) *Team {
+func NewTeam(color Color, spawn mgl64.Vec3, eggPos cube.Pos) *Team {
 	return &Team{
-		Name:      name,
-		Color:     color,
-		ColorName: colorName,
-		Spawn:     spawn,
-		EggPos:    eggPos,
-		EggAlive:  true,
-		Players:   make([]string, 0),
-		Generator: generator,
+		Name:     color.Name(),
+		Color:    color,
+		Spawn:    spawn,
+		EggPos:   eggPos,
+		EggAlive: true,
+		Players:  make([]string, 0),
+		tiers:    make(map[Upgrade]int),
 	}
 }
 
+// Title returns the name of the team in its colour, such as "§cTeam Red".
+func (t *Team) Title() string {
+	return t.Color.Code() + "Team " + t.Name
+}
+
 func (t *Team) AddPlayer(playerName string) {
 	t.Players = append(t.Players, playerName)
 }
@@ -0,0 +1,115 @@
+package team
+
+import "time"
+
+// Upgrade is a team-wide upgrade bought in tiers, lasting for the rest of the
+// match.
+type Upgrade string
+
+const (
+	// Sharpness enchants the swords and axes of all players of the team.
+	Sharpness Upgrade = "sharpness"
+	// Protection enchants the armour of all players of the team.
+	Protection Upgrade = "protection"
+	// Haste gives players of the team haste while on their island.
+	Haste Upgrade = "haste"
+	// Regeneration gives players of the team regeneration while on their
+	// island.
+	Regeneration Upgrade = "regeneration"
+	// Forge speeds up the generators of the team.
+	Forge Upgrade = "forge"
+)
+
+// Upgrades holds all upgrades in the order they are shown in the upgrade menu.
+var Upgrades = []Upgrade{Sharpness, Protection, Haste, Regeneration, Forge}
+
+// Title returns the name of the upgrade as shown to players.
+func (u Upgrade) Title() string {
+	switch u {
+	case Sharpness:
+		return "Sharpened Swords"
+	case Protection:
+		return "Reinforced Armour"
+	case Haste:
+		return "Maniac Miner"
+	case Regeneration:
+		return "Heal Pool"
+	default:
+		return "Forge"
+	}
+}
+
+// Trap is a trap set on the island of a team. It triggers once when an enemy
+// enters the island.
+type Trap string
+
+const (
+	// BlindnessTrap blinds and slows down the enemy.
+	BlindnessTrap Trap = "blindness"
+	// FatigueTrap gives the enemy mining fatigue.
+	FatigueTrap Trap = "fatigue"
+	// AlarmTrap reveals invisible enemies.
+	AlarmTrap Trap = "alarm"
+)
+
+// Traps holds all traps in the order they are shown in the upgrade menu.
+var Traps = []Trap{BlindnessTrap, FatigueTrap, AlarmTrap}
+
+// Title returns the name of the trap as shown to players.
+func (t Trap) Title() string {
+	switch t {
+	case BlindnessTrap:
+		return "It's a Trap!"
+	case FatigueTrap:
+		return "Miner Fatigue Trap"
+	default:
+		return "Alarm Trap"
+	}
+}
+
+// MaxTraps is the most traps a team may have set at once.
+const MaxTraps = 3
+
+// Tier returns the tier of the upgrade passed the team bought. It is 0 if the
+// team did not buy the upgrade.
+func (t *Team) Tier(u Upgrade) int {
+	return t.tiers[u]
+}
+
+// Raise raises the tier of the upgrade passed by one and returns the new tier.
+func (t *Team) Raise(u Upgrade) int {
+	if t.tiers == nil {
+		t.tiers = make(map[Upgrade]int)
+	}
+	t.tiers[u]++
+	return t.tiers[u]
+}
+
+// Traps returns the traps set on the team's island, in the order they
+// trigger.
+func (t *Team) Traps() []Trap {
+	return t.traps
+}
+
+// SetTrap adds a trap to the team's island. It returns false if MaxTraps are
+// already set.
+func (t *Team) SetTrap(trap Trap) bool {
+	if len(t.traps) >= MaxTraps {
+		return false
+	}
+	t.traps = append(t.traps, trap)
+	return true
+}
+
+// TriggerTrap removes and returns the trap set first on the team's island, if
+// traps may trigger at the time passed. The next trap may trigger no sooner
+// than cooldown later.
+func (t *Team) TriggerTrap(now time.Time, cooldown time.Duration) (Trap, bool) {
+	if len(t.traps) == 0 || now.Before(t.trapsArmed) {
+		return "", false
+	}
+	trap := t.traps[0]
+	t.traps = t.traps[1:]
+	t.trapsArmed = now.Add(cooldown)
+	return trap, true
+}
@@ -0,0 +1,59 @@
+package utility
+
+import (
+	"slices"
+
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/block/cube/trace"
+	"github.com/df-mc/dragonfly/server/entity"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// NewFireball creates a fireball thrown by owner. It explodes with a size of
+// FireballPower once it hits a block or an entity.
+func NewFireball(opts world.EntitySpawnOpts, owner world.Entity) *world.EntityHandle {
+	conf := fireballConf
+	conf.Owner = owner.H()
+	return opts.New(FireballType, conf)
+}
+
+var fireballConf = entity.ProjectileBehaviourConfig{
+	// Fireballs barely fall, but do so enough to end up in the void if they
+	// never hit anything.
+	Gravity: 0.005,
+	// Fireballs hurt and knock back through their explosion alone.
+	Damage: -1,
+	Hit: func(e *entity.Ent, tx *world.Tx, target trace.Result) {
+		block.ExplosionConfig{Size: FireballPower, ItemDropChance: -1}.Explode(tx, target.Position())
+	},
+}
+
+// FireballType is a world.EntityType implementation for fireballs.
+var FireballType fireballType
+
+// Entities returns reg with the entity types of utility items added, replacing
+// any registered under the same name. Worlds that utility items are used in
+// must be created with it, so that their entities can be saved and shown.
+func Entities(reg world.EntityRegistry) world.EntityRegistry {
+	types := slices.DeleteFunc(reg.Types(), func(t world.EntityType) bool {
+		return t.EncodeEntity() == FireballType.EncodeEntity()
+	})
+	return reg.Config().New(append(types, FireballType))
+}
+
+type fireballType struct{}
+
+func (fireballType) Open(tx *world.Tx, handle *world.EntityHandle, data *world.EntityData) world.Entity {
+	return entity.Open(tx, handle, data)
+}
+
+func (fireballType) EncodeEntity() string { return "minecraft:fireball" }
+func (fireballType) BBox(world.Entity) cube.BBox {
+	return cube.Box(-0.3125, 0, -0.3125, 0.3125, 0.625, 0.3125)
+}
+
+func (fireballType) DecodeNBT(_ map[string]any, data *world.EntityData) {
+	data.Data = fireballConf.New()
+}
+func (fireballType) EncodeNBT(*world.EntityData) map[string]any { return nil }
@@ -0,0 +1,45 @@
+package utility
+
+import (
+	"image"
+
+	"github.com/df-mc/dragonfly/server/block"
+	"github.com/df-mc/dragonfly/server/block/cube"
+	"github.com/df-mc/dragonfly/server/block/customblock"
+	"github.com/df-mc/dragonfly/server/block/model"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// Slime is the block rescue platforms are made of. It looks like a slime
+// block, and entities landing on it take no damage. Dragonfly does not
+// implement slime blocks, so Slime is a custom block of its own rather than
+// taking the place of the vanilla one.
+type Slime struct{}
+
+// slimeHash is the base hash of Slime.
+var slimeHash = block.NextHash()
+
+// EntityLand cancels the fall damage of entities landing on the block.
+func (Slime) EntityLand(_ cube.Pos, _ *world.Tx, _ world.Entity, distance *float64) {
+	*distance = 0
+}
+
+func (Slime) Name() string     { return "Rescue Platform" }
+func (Slime) Geometry() []byte { return nil }
+func (Slime) Textures() map[string]image.Image {
+	return map[string]image.Image{"eggwars_rescue_slime": slimeTexture}
+}
+func (Slime) Properties() customblock.Properties {
+	return customblock.Properties{
+		CollisionBox: cube.Box(0, 0, 0, 1, 1, 1),
+		SelectionBox: cube.Box(0, 0, 0, 1, 1, 1),
+		Cube:         true,
+		Textures: map[string]customblock.Material{
+			"*": customblock.NewMaterial("eggwars_rescue_slime", customblock.BlendRenderMethod()),
+		},
+	}
+}
+
+func (Slime) EncodeBlock() (string, map[string]any) { return "eggwars:rescue_slime", nil }
+func (Slime) Hash() (uint64, uint64)                { return slimeHash, 0 }
+func (Slime) Model() world.BlockModel               { return model.Solid{} }
@@ -0,0 +1,152 @@
+package utility
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// palette maps the characters of a texture drawn by texture to colours. Any
+// character not in the palette is transparent.
+type palette map[byte]color.RGBA
+
+// texture draws a 16x16 texture from 16 rows of 16 characters each, every
+// character being one pixel.
+func texture(p palette, rows ...string) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	if len(rows) != 16 {
+		panic(fmt.Sprintf("texture has %d rows, expected 16", len(rows)))
+	}
+	for y, row := range rows {
+		if len(row) != 16 {
+			panic(fmt.Sprintf("texture row %d is %d pixels wide, expected 16", y, len(row)))
+		}
+		for x := range len(row) {
+			if c, ok := p[row[x]]; ok {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+	return img
+}
+
+var bridgeEggTexture = texture(palette{
+	'o': {R: 0x5a, G: 0x4a, B: 0x3a, A: 0xff},
+	'w': {R: 0xf0, G: 0xe6, B: 0xc8, A: 0xff},
+	'h': {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	's': {R: 0x4a, G: 0x9a, B: 0xe0, A: 0xff},
+},
+	"................",
+	"......oooo......",
+	".....owwwwo.....",
+	"....owwhwwwo....",
+	"....owhwwswo....",
+	"...owwwwwwwwo...",
+	"...owswwwwwwo...",
+	"...owwwwwswwo...",
+	"..owwwwwwwwwwo..",
+	"..owwswwwwwwwo..",
+	"..owwwwwwwswwo..",
+	"..owwwwwwwwwwo..",
+	"...owwwswwwwo...",
+	"...owwwwwwwwo...",
+	"....oowwwwoo....",
+	"......oooo......",
+)
+
+var instantTNTTexture = texture(palette{
+	'r': {R: 0xdb, G: 0x2e, B: 0x1e, A: 0xff},
+	'R': {R: 0x9c, G: 0x1c, B: 0x12, A: 0xff},
+	'w': {R: 0xf2, G: 0xf2, B: 0xf2, A: 0xff},
+	'k': {R: 0x20, G: 0x20, B: 0x20, A: 0xff},
+	'y': {R: 0xff, G: 0xd8, B: 0x30, A: 0xff},
+},
+	".......y........",
+	".......k........",
+	".rrrrrrrrrrrrrr.",
+	".rRrrRrrRrrRrrR.",
+	".rrrrrrrrrrrrrr.",
+	".wwwwwwwwwwwwww.",
+	".wkkkwkwwkwkkkw.",
+	".wwkwwkkwkwwkww.",
+	".wwkwwkwkkwwkww.",
+	".wwkwwkwwkwwkww.",
+	".wwwwwwwwwwwwww.",
+	".rrrrrrrrrrrrrr.",
+	".rRrrRrrRrrRrrR.",
+	".rrrrrrrrrrrrrr.",
+	".rRrrRrrRrrRrrR.",
+	"................",
+)
+
+var fireballTexture = texture(palette{
+	'd': {R: 0x6e, G: 0x12, B: 0x0a, A: 0xff},
+	'r': {R: 0xc8, G: 0x32, B: 0x0c, A: 0xff},
+	'o': {R: 0xf0, G: 0x7a, B: 0x14, A: 0xff},
+	'y': {R: 0xff, G: 0xc8, B: 0x2a, A: 0xff},
+	'h': {R: 0xff, G: 0xf4, B: 0xb0, A: 0xff},
+},
+	"................",
+	".....dddddd.....",
+	"...ddrrrrrrdd...",
+	"..drroooooorrd..",
+	"..droooyyooord..",
+	".drooyyyyyyoord.",
+	".droyyyhhyyyord.",
+	".droyyhhhhyyord.",
+	".droyyhhhhyyord.",
+	".droyyyhhyyyord.",
+	".drooyyyyyyoord.",
+	"..droooyyooord..",
+	"..drroooooorrd..",
+	"...ddrrrrrrdd...",
+	".....dddddd.....",
+	"................",
+)
+
+var rescuePlatformTexture = texture(palette{
+	'g': {R: 0x3c, G: 0x8a, B: 0x2e, A: 0xff},
+	'l': {R: 0x7c, G: 0xd0, B: 0x5c, A: 0xff},
+	'L': {R: 0xb4, G: 0xf0, B: 0x96, A: 0xff},
+	'k': {R: 0x1e, G: 0x4a, B: 0x16, A: 0xff},
+},
+	"................",
+	".gggggggggggggg.",
+	".gLLLllllllllLg.",
+	".gLlllllllllllg.",
+	".gllkkllllkkllg.",
+	".gllkkllllkkllg.",
+	".gllllllllllllg.",
+	".gllllllllllllg.",
+	".glllllkklllllg.",
+	".gllllllllllllg.",
+	".gllllllllllllg.",
+	".gggggggggggggg.",
+	"................",
+	"..gggggggggggg..",
+	"..gllllllllllg..",
+	"..gggggggggggg..",
+)
+
+var slimeTexture = texture(palette{
+	'o': {R: 0x5f, G: 0xb3, B: 0x4b, A: 0xe0},
+	'i': {R: 0x83, G: 0xd4, B: 0x6c, A: 0xa0},
+	'h': {R: 0xb9, G: 0xf0, B: 0xa2, A: 0xb0},
+},
+	"oooooooooooooooo",
+	"oiiiiiiiiiiiiiio",
+	"oihhiiiiiiiiiiio",
+	"oihiiiiiiiiiiiio",
+	"oiiiooooooooiiio",
+	"oiiioiiiiiiioiio",
+	"oiiioihiiiiioiio",
+	"oiiioiiiiiiioiio",
+	"oiiioiiiiiiioiio",
+	"oiiioiiiiiiioiio",
+	"oiiioiiiiiiioiio",
+	"oiiiooooooooiiio",
+	"oiiiiiiiiiiiiiio",
+	"oiiiiiiiiiiiiiio",
+	"oiiiiiiiiiiiiiio",
+	"oooooooooooooooo",
+)
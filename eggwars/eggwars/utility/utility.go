@@ -0,0 +1,113 @@
+package utility
+
+import (
+	"image"
+	"time"
+
+	"github.com/df-mc/dragonfly/server/item"
+	"github.com/df-mc/dragonfly/server/item/category"
+	"github.com/df-mc/dragonfly/server/world"
+)
+
+// Item is a special item sold in the shop. Items do nothing by themselves:
+// using one is handled by the arena of the player holding it, as most need to
+// know the player's team.
+type Item interface {
+	world.CustomItem
+	// Cooldown is the time a player must wait after using the item before it
+	// may be used again.
+	Cooldown() time.Duration
+}
+
+const (
+	// BridgeLength is the most blocks a bridge egg places.
+	BridgeLength = 32
+	// BridgeDuration is the longest time a bridge egg places blocks for.
+	BridgeDuration = 2 * time.Second
+	// TNTFuse is the time instant TNT takes to explode once placed.
+	TNTFuse = 2500 * time.Millisecond
+	// FireballSpeed is the speed of fireballs in blocks per tick.
+	FireballSpeed = 1.2
+	// FireballPower is the size of the explosion of fireballs.
+	FireballPower = 1.5
+	// PlatformRadius is the number of blocks a rescue platform extends to
+	// every side of the block below the player.
+	PlatformRadius = 1
+	// PlatformDuration is the time a rescue platform lasts.
+	PlatformDuration = 8 * time.Second
+)
+
+// BridgeEgg is an egg that places wool in the colour of the thrower's team
+// along its trajectory.
+type BridgeEgg struct{}
+
+func (BridgeEgg) EncodeItem() (name string, meta int16) { return "eggwars:bridge_egg", 0 }
+func (BridgeEgg) Name() string                          { return "Bridge Egg" }
+func (BridgeEgg) Texture() image.Image                  { return bridgeEggTexture }
+func (BridgeEgg) Category() category.Category           { return category.Items() }
+func (BridgeEgg) Cooldown() time.Duration               { return 3 * time.Second }
+func (BridgeEgg) MaxCount() int                         { return 16 }
+func (BridgeEgg) SwingAnimation() bool                  { return true }
+
+// InstantTNT is TNT that ignites itself as soon as it is placed.
+type InstantTNT struct{}
+
+func (InstantTNT) EncodeItem() (name string, meta int16) { return "eggwars:instant_tnt", 0 }
+func (InstantTNT) Name() string                          { return "Instant TNT" }
+func (InstantTNT) Texture() image.Image                  { return instantTNTTexture }
+func (InstantTNT) Category() category.Category           { return category.Items() }
+func (InstantTNT) Cooldown() time.Duration               { return time.Second / 2 }
+
+// Fireball is a fireball thrown in the direction the player looks. It
+// explodes on impact, knocking back everyone nearby.
+type Fireball struct{}
+
+func (Fireball) EncodeItem() (name string, meta int16) { return "eggwars:throwable_fireball", 0 }
+func (Fireball) Name() string                          { return "Fireball" }
+func (Fireball) Texture() image.Image                  { return fireballTexture }
+func (Fireball) Category() category.Category           { return category.Items() }
+func (Fireball) Cooldown() time.Duration               { return time.Second }
+func (Fireball) MaxCount() int                         { return 16 }
+func (Fireball) SwingAnimation() bool                  { return true }
+
+// RescuePlatform spawns a platform of slime below a falling player, which
+// disappears after PlatformDuration.
+type RescuePlatform struct{}
+
+func (RescuePlatform) EncodeItem() (name string, meta int16) { return "eggwars:rescue_platform", 0 }
+func (RescuePlatform) Name() string                          { return "Rescue Platform" }
+func (RescuePlatform) Texture() image.Image                  { return rescuePlatformTexture }
+func (RescuePlatform) Category() category.Category           { return category.Items() }
+func (RescuePlatform) Cooldown() time.Duration               { return 15 * time.Second }
+
+// TrackerName is the name that shop items and kits use for enemy trackers.
+// Trackers are not items of their own: only vanilla compasses can point at a
+// target, so trackers are compasses marked with a value.
+const TrackerName = "eggwars:enemy_tracker"
+
+// TrackerCooldown is the time a player must wait after using a tracker before
+// it may be used again.
+const TrackerCooldown = time.Second
+
+// trackerKey is the key of the value that marks compasses as trackers.
+const trackerKey = "eggwars:tracker"
+
+// NewTracker returns a compass that points at the nearest enemy while held,
+// showing their name and distance. It is not used up.
+func NewTracker() item.Stack {
+	return item.NewStack(item.Compass{}, 1).WithCustomName("§rEnemy Tracker").WithValue(trackerKey, true)
+}
+
+// IsTracker checks if s is a tracker returned by NewTracker.
+func IsTracker(s item.Stack) bool {
+	_, ok := s.Value(trackerKey)
+	return ok
+}
+
+func init() {
+	world.RegisterItem(BridgeEgg{})
+	world.RegisterItem(InstantTNT{})
+	world.RegisterItem(Fireball{})
+	world.RegisterItem(RescuePlatform{})
+	world.RegisterBlock(Slime{})
+}
@@ -32,6 +32,7 @@ func main() {
 
 		go eggMgr.HandlePlayer(p)
 	}
+	eggMgr.Close()
 }
 
 func readConfig(log *logrus.Logger) *server.Server {
@@ -42,6 +42,7 @@ func main() {
 
 		go eggMgr.HandlePlayer(p)
 	}
+	eggMgr.Close()
 }
 
 // readConfig reads the configuration from the config.toml file, or creates the
@@ -262,6 +262,15 @@ func (p *Player) SendToast(title, message string) {
 	p.session().SendToast(title, message)
 }
 
+// SetCompassTarget makes compasses held by the player point to pos instead of the spawn of the world. Clients point
+// compasses at the world spawn and servers have no other way of changing where they point, so SetCompassTarget sends
+// the player pos as the world spawn. This affects every compass of the player, as well as anything else the client
+// derives from the world spawn, until the target is set again or the player moves to another world, which sends the
+// real world spawn. The spawn of the world itself is not changed.
+func (p *Player) SetCompassTarget(pos cube.Pos) {
+	p.session().ViewWorldSpawn(pos)
+}
+
 // ResetFallDistance resets the player's fall distance.
 func (p *Player) ResetFallDistance() {
 	p.fallDistance = 0
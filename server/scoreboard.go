@@ -1,25 +1,40 @@
-package server
-
-import (
-	"github.com/df-mc/dragonfly/server/player"
-)
-
-type Scoreboard struct {
-	Title string
-	Lines []string
-}
-
-func (s *Scoreboard) Update(p *player.Player) {
-	// Logic to update the scoreboard for the player.
-	p.Message("Scoreboard updated: " + s.Title)
-}
-
-func NewGameScoreboard() *Scoreboard {
-	return &Scoreboard{
-		Title: "EggWars",
-		Lines: []string{
-			"Players: 0",
-			"Time Left: 10:00",
-		},
-	}
-}
+package server
+
+import (
+	"github.com/df-mc/dragonfly/server/player"
+	"github.com/df-mc/dragonfly/server/player/scoreboard"
+)
+
+// Scoreboard is a sidebar with a title and lines of text.
+//
+// Deprecated: Use scoreboard.Scoreboard and player.Player.SendScoreboard.
+type Scoreboard struct {
+	Title string
+	Lines []string
+}
+
+// Update sends the scoreboard to p, replacing the one it currently sees.
+//
+// Deprecated: Use player.Player.SendScoreboard.
+func (s *Scoreboard) Update(p *player.Player) {
+	sb := scoreboard.New(s.Title)
+	sb.RemovePadding()
+	for i, line := range s.Lines {
+		sb.Set(i, line)
+	}
+	p.SendScoreboard(sb)
+}
+
+// NewGameScoreboard returns a Scoreboard with the title and lines shown to
+// players of a game before it starts.
+//
+// Deprecated: Use scoreboard.New.
+func NewGameScoreboard() *Scoreboard {
+	return &Scoreboard{
+		Title: "EggWars",
+		Lines: []string{
+			"Players: 0",
+			"Time Left: 10:00",
+		},
+	}
+}
@@ -89,25 +89,30 @@ func (s *Session) SendScoreboard(sb *scoreboard.Scoreboard) {
 			pk.SortOrder = packet.ScoreboardSortOrderAscending
 		}
 		s.writePacket(pk)
-		name, lines := sb.Name(), append([]string(nil), sb.Lines()...)
-		s.currentScoreboard.Store(&name)
-		s.currentLines.Store(&lines)
-	} else {
-		// Remove all current lines from the scoreboard. We can't replace them without removing them.
-		pk := &packet.SetScore{ActionType: packet.ScoreboardActionRemove}
-		for i := range currentLines {
-			pk.Entries = append(pk.Entries, protocol.ScoreboardEntry{
+		currentLines = nil
+	}
+	lines := sb.Lines()
+
+	// Only lines that changed are sent again. Lines can't be replaced without removing them first, so changed
+	// lines and lines that are no longer present are removed.
+	remove := &packet.SetScore{ActionType: packet.ScoreboardActionRemove}
+	for i, line := range currentLines {
+		if i >= len(lines) || lines[i] != line {
+			remove.Entries = append(remove.Entries, protocol.ScoreboardEntry{
 				EntryID:       int64(i),
-				ObjectiveName: currentName,
+				ObjectiveName: sb.Name(),
 				Score:         int32(i),
 			})
 		}
-		if len(pk.Entries) > 0 {
-			s.writePacket(pk)
-		}
+	}
+	if len(remove.Entries) > 0 {
+		s.writePacket(remove)
 	}
 	pk := &packet.SetScore{ActionType: packet.ScoreboardActionModify}
-	for k, line := range sb.Lines() {
+	for k, line := range lines {
+		if k < len(currentLines) && currentLines[k] == line {
+			continue
+		}
 		if len(line) == 0 {
 			line = "§" + colours[k]
 		}
@@ -122,6 +127,9 @@ func (s *Session) SendScoreboard(sb *scoreboard.Scoreboard) {
 	if len(pk.Entries) > 0 {
 		s.writePacket(pk)
 	}
+	name, stored := sb.Name(), append([]string(nil), lines...)
+	s.currentScoreboard.Store(&name)
+	s.currentLines.Store(&stored)
 }
 
 // colours holds a list of colour codes to be filled out for empty lines in a scoreboard.
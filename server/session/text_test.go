@@ -0,0 +1,142 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/df-mc/dragonfly/server/player/scoreboard"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// newScoreboardSession returns a Session that only supports sending
+// scoreboards. Packets written to it are buffered in its packets channel.
+func newScoreboardSession() *Session {
+	s := &Session{packets: make(chan packet.Packet, 64), closeBackground: make(chan struct{})}
+	var name string
+	var lines []string
+	s.currentScoreboard.Store(&name)
+	s.currentLines.Store(&lines)
+	return s
+}
+
+// written returns all packets written to s since the last call.
+func written(s *Session) []packet.Packet {
+	var pks []packet.Packet
+	for {
+		select {
+		case pk := <-s.packets:
+			pks = append(pks, pk)
+		default:
+			return pks
+		}
+	}
+}
+
+func newScoreboard(name string, lines ...string) *scoreboard.Scoreboard {
+	sb := scoreboard.New(name)
+	sb.RemovePadding()
+	for i, line := range lines {
+		sb.Set(i, line)
+	}
+	return sb
+}
+
+// scoreEntries returns the entry IDs and texts of the SetScore packets in pks
+// with the action passed.
+func scoreEntries(pks []packet.Packet, action byte) map[int64]string {
+	entries := make(map[int64]string)
+	for _, pk := range pks {
+		if pk, ok := pk.(*packet.SetScore); ok && pk.ActionType == action {
+			for _, e := range pk.Entries {
+				entries[e.EntryID] = e.DisplayName
+			}
+		}
+	}
+	return entries
+}
+
+func TestSendScoreboardDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		first      *scoreboard.Scoreboard
+		second     *scoreboard.Scoreboard
+		wantRemove []int64
+		wantModify map[int64]string
+		wantNew    bool
+	}{
+		{
+			name:       "unchanged",
+			first:      newScoreboard("A", "one", "two"),
+			second:     newScoreboard("A", "one", "two"),
+			wantModify: map[int64]string{},
+		},
+		{
+			name:       "one line changed",
+			first:      newScoreboard("A", "one", "two", "three"),
+			second:     newScoreboard("A", "one", "2", "three"),
+			wantRemove: []int64{1},
+			wantModify: map[int64]string{1: "2"},
+		},
+		{
+			name:       "line added",
+			first:      newScoreboard("A", "one"),
+			second:     newScoreboard("A", "one", "two"),
+			wantModify: map[int64]string{1: "two"},
+		},
+		{
+			name:       "line removed",
+			first:      newScoreboard("A", "one", "two"),
+			second:     newScoreboard("A", "one"),
+			wantRemove: []int64{1},
+			wantModify: map[int64]string{},
+		},
+		{
+			name:       "new scoreboard",
+			first:      newScoreboard("A", "one", "two"),
+			second:     newScoreboard("B", "one", "two"),
+			wantModify: map[int64]string{0: "one", 1: "two"},
+			wantNew:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScoreboardSession()
+			s.SendScoreboard(tt.first)
+			written(s)
+
+			s.SendScoreboard(tt.second)
+			pks := written(s)
+
+			var gotNew bool
+			for _, pk := range pks {
+				if _, ok := pk.(*packet.SetDisplayObjective); ok {
+					gotNew = true
+				}
+			}
+			if gotNew != tt.wantNew {
+				t.Errorf("objective sent again: got %v, want %v", gotNew, tt.wantNew)
+			}
+			removed := scoreEntries(pks, packet.ScoreboardActionRemove)
+			if len(removed) != len(tt.wantRemove) {
+				t.Errorf("removed entries: got %v, want %v", removed, tt.wantRemove)
+			}
+			for _, id := range tt.wantRemove {
+				if _, ok := removed[id]; !ok {
+					t.Errorf("removed entries: got %v, want %v", removed, tt.wantRemove)
+				}
+			}
+			modified := scoreEntries(pks, packet.ScoreboardActionModify)
+			if len(modified) != len(tt.wantModify) {
+				t.Errorf("modified entries: got %v, want %v", modified, tt.wantModify)
+			}
+			for id, line := range tt.wantModify {
+				if modified[id] != line {
+					t.Errorf("modified entry %d: got %q, want %q", id, modified[id], line)
+				}
+			}
+
+			if got := *s.currentLines.Load(); len(got) != len(tt.second.Lines()) {
+				t.Errorf("current lines: got %v, want %v", got, tt.second.Lines())
+			}
+		})
+	}
+}